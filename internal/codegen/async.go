@@ -0,0 +1,66 @@
+package codegen
+
+import (
+	"strings"
+
+	"github.com/tandemdude/sqlc-gen-java/internal/core"
+)
+
+// asyncMethodName returns the name of the asynchronous variant of the method generated for the given query.
+func asyncMethodName(q core.Query) string {
+	return q.MethodName + "Async"
+}
+
+// asyncReturnType resolves the return type of the asynchronous variant of the method generated for the given query.
+func asyncReturnType(q core.Query, t *importTracker) string {
+	return t.Type("java.util.concurrent.CompletableFuture") + "<" + boxedReturnType(q, t) + ">"
+}
+
+// asyncExecutorParam returns the declaration of the executor parameter of each asynchronous method.
+func asyncExecutorParam(t *importTracker, nonNullAnnotation string) string {
+	return strings.TrimSpace(nonNullAnnotation+" "+t.Type("java.util.concurrent.Executor")) + " executor"
+}
+
+// callArgs returns the arguments passed when calling the method generated for the given query from within another
+// method with the same parameters.
+func callArgs(q core.Query) string {
+	if q.Command.TakesParamsList() || q.UseParamsRecord {
+		return "params"
+	}
+
+	names := make([]string, 0, len(q.Args))
+	for _, arg := range q.Args {
+		names = append(names, arg.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// writeAsyncMethod writes the asynchronous variant of the method generated for the given query, which calls the
+// synchronous method using the given executor. Checked exceptions are rethrown wrapped in a CompletionException, so
+// that they complete the returned future exceptionally.
+func (b *IndentStringBuilder) writeAsyncMethod(modifiers string, config core.Config, q core.Query, t *importTracker, nonNullAnnotation, nullableAnnotation string) {
+	b.writeMethodSignature(modifiers, asyncReturnType(q, t), asyncMethodName(q), q, "", t, nonNullAnnotation, nullableAnnotation, asyncExecutorParam(t, nonNullAnnotation))
+	b.WriteString(" {\n")
+
+	future := t.Type("java.util.concurrent.CompletableFuture")
+	method, call := "supplyAsync", q.MethodName+"("+callArgs(q)+")"
+	statement := "return " + call + ";"
+	if q.Command == core.Exec {
+		method, statement = "runAsync", call+";"
+	}
+
+	if throwsClause(config) == "" {
+		b.WriteIndentedString(2, "return "+future+"."+method+"(() -> "+call+", executor);\n")
+		b.WriteIndentedString(1, "}\n")
+		return
+	}
+
+	b.WriteIndentedString(2, "return "+future+"."+method+"(() -> {\n")
+	b.WriteIndentedString(3, "try {\n")
+	b.WriteIndentedString(4, statement+"\n")
+	b.WriteIndentedString(3, "} catch (SQLException e) {\n")
+	b.WriteIndentedString(4, "throw new "+t.Type("java.util.concurrent.CompletionException")+"(e);\n")
+	b.WriteIndentedString(3, "}\n")
+	b.WriteIndentedString(2, "}, executor);\n")
+	b.WriteIndentedString(1, "}\n")
+}
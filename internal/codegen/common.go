@@ -42,8 +42,7 @@ type nullableHelper struct {
 	ArgType      string
 }
 
-func (b *IndentStringBuilder) writeNullableHelpers(nullableHelpers core.NullableHelpers, nonNullAnnotation, nullableAnnotation string) []string {
-	imports := make([]string, 0)
+func (b *IndentStringBuilder) writeNullableHelpers(nullableHelpers core.NullableHelpers, t *importTracker, nonNullAnnotation, nullableAnnotation string) {
 	methodTypes := []nullableHelper{
 		{nullableHelpers.Int, "Integer", "Int"},
 		{nullableHelpers.Long, "Long", "Long"},
@@ -61,7 +60,7 @@ func (b *IndentStringBuilder) writeNullableHelpers(nullableHelpers core.Nullable
 			"private static %s get%s(%s rs, int col) throws SQLException {\n",
 			core.Annotate(methodType.ReturnType, nullableAnnotation),
 			methodType.ArgType,
-			core.Annotate("ResultSet", nonNullAnnotation),
+			core.Annotate(t.Type("java.sql.ResultSet"), nonNullAnnotation),
 		))
 		b.WriteIndentedString(2, fmt.Sprintf(
 			"var colVal = rs.get%s(col); return rs.wasNull() ? null : colVal;\n",
@@ -73,28 +72,60 @@ func (b *IndentStringBuilder) writeNullableHelpers(nullableHelpers core.Nullable
 	if nullableHelpers.List {
 		b.WriteIndentedString(1, fmt.Sprintf(
 			"private static <T> %s getList(%s rs, int col, Class<T[]> as) throws SQLException {\n",
-			core.Annotate("List<T>", nullableAnnotation),
-			core.Annotate("ResultSet", nonNullAnnotation),
+			core.Annotate(t.Type("java.util.List")+"<T>", nullableAnnotation),
+			core.Annotate(t.Type("java.sql.ResultSet"), nonNullAnnotation),
 		))
-		b.WriteIndentedString(2, "var colVal = rs.getArray(col); return colVal == null ? null : Arrays.asList(as.cast(colVal.getArray()));\n")
+		b.WriteIndentedString(2, "var colVal = rs.getArray(col); return colVal == null ? null : "+t.Type("java.util.Arrays")+".asList(as.cast(colVal.getArray()));\n")
 		b.WriteIndentedString(1, "}\n")
+	}
 
-		imports = append(imports, "java.util.List")
+	// sqlite has no dedicated date/time storage class, values may be either text or epoch milliseconds
+	temporalTypes := []nullableHelper{
+		{nullableHelpers.LocalDate, "java.time.LocalDate", "LocalDate"},
+		{nullableHelpers.LocalTime, "java.time.LocalTime", "LocalTime"},
+		{nullableHelpers.LocalDateTime, "java.time.LocalDateTime", "LocalDateTime"},
 	}
 
-	return imports
-}
+	for _, temporalType := range temporalTypes {
+		if !temporalType.ShouldOutput {
+			continue
+		}
+
+		b.WriteIndentedString(1, fmt.Sprintf(
+			"private static %s get%s(%s rs, int col) throws SQLException {\n",
+			core.Annotate(temporalType.ReturnType, nullableAnnotation),
+			temporalType.ArgType,
+			core.Annotate(t.Type("java.sql.ResultSet"), nonNullAnnotation),
+		))
+		b.WriteIndentedString(2, "var colVal = rs.getObject(col);\n")
+		b.WriteIndentedString(2, "if (colVal == null) return null;\n")
+		b.WriteIndentedString(2, fmt.Sprintf(
+			"if (colVal instanceof Number) return %s.ofInstant(java.time.Instant.ofEpochMilli(((Number) colVal).longValue()), java.time.ZoneOffset.UTC);\n",
+			temporalType.ReturnType,
+		))
 
-func (b *IndentStringBuilder) writeParameter(javaType core.JavaType, name, nonNullAnnotation, nullableAnnotation string) ([]string, error) {
-	imp, jt, err := core.ResolveImportAndType(javaType.Type)
-	if err != nil {
-		return nil, err
+		switch temporalType.ArgType {
+		case "LocalDate":
+			b.WriteIndentedString(2, "var text = colVal.toString(); return java.time.LocalDate.parse(text.length() > 10 ? text.substring(0, 10) : text);\n")
+		case "LocalTime":
+			b.WriteIndentedString(2, "return java.time.LocalTime.parse(colVal.toString());\n")
+		case "LocalDateTime":
+			b.WriteIndentedString(2, "return java.time.LocalDateTime.parse(colVal.toString().replace(' ', 'T'));\n")
+		}
+		b.WriteIndentedString(1, "}\n")
 	}
-	imports := []string{imp}
+}
+
+func (b *IndentStringBuilder) writeParameter(javaType core.JavaType, name string, t *importTracker, nonNullAnnotation, nullableAnnotation string) {
+	b.WriteIndentedString(2, parameterType(javaType, t, nonNullAnnotation, nullableAnnotation)+" "+name)
+}
 
+// parameterType returns the annotated type a value of the given java type is declared with, unboxing non-null
+// primitive types.
+func parameterType(javaType core.JavaType, t *importTracker, nonNullAnnotation, nullableAnnotation string) string {
+	jt := t.Type(javaType.Type)
 	if javaType.IsList {
-		imports = append(imports, "java.util.List")
-		jt = "List<" + jt + ">"
+		jt = t.Type("java.util.List") + "<" + jt + ">"
 	}
 
 	annotation := nonNullAnnotation
@@ -102,11 +133,217 @@ func (b *IndentStringBuilder) writeParameter(javaType core.JavaType, name, nonNu
 		annotation = nullableAnnotation
 	}
 
-	newType, unboxed := core.MaybeUnbox(javaType.Type, javaType.IsNullable)
+	// lists can never contain primitive types
+	newType, unboxed := core.MaybeUnbox(javaType.Type, javaType.IsNullable || javaType.IsList)
 	if !unboxed {
 		newType = core.Annotate(jt, annotation)
 	}
+	return newType
+}
+
+// writeStreamHelpers writes the methods used by the streaming variants of :many queries. The statement and result set
+// remain open until the returned stream is closed, rows are fetched from the database in batches of the configured
+// fetch size, or one at a time for mysql. A connection borrowed from the data source is also held until the stream is
+// closed.
+func (b *IndentStringBuilder) writeStreamHelpers(engine string, config core.Config, t *importTracker, nonNullAnnotation, nullableAnnotation string) {
+	b.WriteString("\n")
+	b.WriteIndentedString(1, "@FunctionalInterface\n")
+	b.WriteIndentedString(1, "private interface StatementBinder {\n")
+	b.WriteIndentedString(2, "void bind("+core.Annotate("java.sql.PreparedStatement", nonNullAnnotation)+" stmt) throws SQLException;\n")
+	b.WriteIndentedString(1, "}\n\n")
+	b.WriteIndentedString(1, "@FunctionalInterface\n")
+	b.WriteIndentedString(1, "private interface RowMapper<T> {\n")
+	b.WriteIndentedString(2, "T map("+core.Annotate(t.Type("java.sql.ResultSet"), nonNullAnnotation)+" results) throws SQLException;\n")
+	b.WriteIndentedString(1, "}\n\n")
+
+	b.WriteIndentedString(1, fmt.Sprintf(
+		"private <T> %s<T> streamResults(%s query, %s binder, %s mapper) throws SQLException {\n",
+		t.Type("java.util.stream.Stream"),
+		core.Annotate("String", nonNullAnnotation),
+		core.Annotate("StatementBinder", nonNullAnnotation),
+		core.Annotate("RowMapper<T>", nonNullAnnotation),
+	))
+	if config.EmitDataSourceConstructor {
+		b.WriteIndentedString(2, "var conn = acquireConnection();\n")
+	}
+	if engine == "postgresql" {
+		// pgjdbc ignores the fetch size and reads the entire result set into memory unless autocommit is disabled
+		b.WriteIndentedString(2, "var restoreAutoCommit = conn.getAutoCommit();\n")
+		b.WriteIndentedString(2, "if (restoreAutoCommit) {\n")
+		b.WriteIndentedString(3, "conn.setAutoCommit(false);\n")
+		b.WriteIndentedString(2, "}\n\n")
+	} else {
+		b.WriteIndentedString(2, "var restoreAutoCommit = false;\n")
+	}
+	b.WriteIndentedString(2, "java.sql.PreparedStatement stmt = null;\n")
+	b.WriteIndentedString(2, "try {\n")
+	b.WriteIndentedString(3, "stmt = conn.prepareStatement(query);\n")
+	if engine == "mysql" {
+		// Connector/J reads the entire result set into memory for any other fetch size unless useCursorFetch is enabled
+		b.WriteIndentedString(3, "stmt.setFetchSize(Integer.MIN_VALUE);\n")
+	} else {
+		b.WriteIndentedString(3, fmt.Sprintf("stmt.setFetchSize(%d);\n", config.StreamFetchSize))
+	}
+	b.WriteIndentedString(3, "binder.bind(stmt);\n")
+	b.WriteIndentedString(3, "var results = stmt.executeQuery();\n\n")
+	b.WriteIndentedString(3, "var spliterator = new java.util.Spliterators.AbstractSpliterator<T>(Long.MAX_VALUE, java.util.Spliterator.ORDERED) {\n")
+	b.WriteIndentedString(4, "@Override\n")
+	b.WriteIndentedString(4, "public boolean tryAdvance(java.util.function.Consumer<? super T> action) {\n")
+	b.WriteIndentedString(5, "try {\n")
+	b.WriteIndentedString(6, "if (!results.next()) return false;\n")
+	b.WriteIndentedString(6, "action.accept(mapper.map(results));\n")
+	b.WriteIndentedString(6, "return true;\n")
+	b.WriteIndentedString(5, "} catch (SQLException e) {\n")
+	b.WriteIndentedString(6, "throw new RuntimeException(e);\n")
+	b.WriteIndentedString(5, "}\n")
+	b.WriteIndentedString(4, "}\n")
+	b.WriteIndentedString(3, "};\n\n")
+	// the connection is passed explicitly when it may have been borrowed from the data source
+	closeArgs := "stmt, restoreAutoCommit"
+	if config.EmitDataSourceConstructor {
+		closeArgs = "conn, " + closeArgs
+	}
+
+	b.WriteIndentedString(3, "var openStmt = stmt;\n")
+	b.WriteIndentedString(3, "return java.util.stream.StreamSupport.stream(spliterator, false).onClose(() -> {\n")
+	b.WriteIndentedString(4, "try {\n")
+	b.WriteIndentedString(5, "closeStream("+strings.Replace(closeArgs, "stmt", "openStmt", 1)+");\n")
+	b.WriteIndentedString(4, "} catch (SQLException e) {\n")
+	b.WriteIndentedString(5, "throw new RuntimeException(e);\n")
+	b.WriteIndentedString(4, "}\n")
+	b.WriteIndentedString(3, "});\n")
+	b.WriteIndentedString(2, "} catch (SQLException | RuntimeException e) {\n")
+	b.WriteIndentedString(3, "try {\n")
+	b.WriteIndentedString(4, "closeStream("+closeArgs+");\n")
+	b.WriteIndentedString(3, "} catch (SQLException suppressed) {\n")
+	b.WriteIndentedString(4, "e.addSuppressed(suppressed);\n")
+	b.WriteIndentedString(3, "}\n")
+	b.WriteIndentedString(3, "throw e;\n")
+	b.WriteIndentedString(2, "}\n")
+	b.WriteIndentedString(1, "}\n\n")
+
+	// closing the statement also closes the result set, restoring autocommit commits the transaction used by the cursor
+	closeParams := core.Annotate("java.sql.PreparedStatement", nullableAnnotation) + " stmt, boolean restoreAutoCommit"
+	if config.EmitDataSourceConstructor {
+		closeParams = core.Annotate("java.sql.Connection", nonNullAnnotation) + " conn, " + closeParams
+	}
+	b.WriteIndentedString(1, "private void closeStream("+closeParams+") throws SQLException {\n")
+	b.WriteIndentedString(2, "try {\n")
+	b.WriteIndentedString(3, "if (stmt != null) stmt.close();\n")
+	b.WriteIndentedString(2, "} finally {\n")
+	if config.EmitDataSourceConstructor {
+		b.WriteIndentedString(3, "try {\n")
+		b.WriteIndentedString(4, "if (restoreAutoCommit) conn.setAutoCommit(true);\n")
+		b.WriteIndentedString(3, "} finally {\n")
+		b.WriteIndentedString(4, "releaseConnection(conn);\n")
+		b.WriteIndentedString(3, "}\n")
+	} else {
+		b.WriteIndentedString(3, "if (restoreAutoCommit) conn.setAutoCommit(true);\n")
+	}
+	b.WriteIndentedString(2, "}\n")
+	b.WriteIndentedString(1, "}\n")
+}
+
+// writeCopyInHelpers writes the methods used to stream the rows of :copyfrom queries using COPY FROM STDIN. pgjdbc is
+// accessed reflectively, so that it is only required at runtime, and only when the connection is provided by it.
+func (b *IndentStringBuilder) writeCopyInHelpers(t *importTracker, nonNullAnnotation, nullableAnnotation string) {
+	b.WriteString("\n")
+	// writes a single quoted CSV field, absent values are written as an unquoted empty string which COPY reads as NULL
+	b.WriteIndentedString(1, fmt.Sprintf(
+		"private static void appendCopyField(%s sb, %s value) {\n",
+		core.Annotate("StringBuilder", nonNullAnnotation),
+		core.Annotate("Object", nullableAnnotation),
+	))
+	b.WriteIndentedString(2, "if (value == null) return;\n\n")
+	b.WriteIndentedString(2, "String text;\n")
+	b.WriteIndentedString(2, "if (value instanceof byte[]) {\n")
+	b.WriteIndentedString(3, "var hex = new StringBuilder(\"\\\\x\");\n")
+	b.WriteIndentedString(3, "for (var octet : (byte[]) value) {\n")
+	b.WriteIndentedString(4, "hex.append(Character.forDigit((octet >> 4) & 0xF, 16)).append(Character.forDigit(octet & 0xF, 16));\n")
+	b.WriteIndentedString(3, "}\n")
+	b.WriteIndentedString(3, "text = hex.toString();\n")
+	b.WriteIndentedString(2, "} else if (value instanceof java.util.Collection) {\n")
+	b.WriteIndentedString(3, "var array = new StringBuilder(\"{\");\n")
+	b.WriteIndentedString(3, "for (var elem : (java.util.Collection<?>) value) {\n")
+	b.WriteIndentedString(4, "if (array.length() > 1) array.append(',');\n")
+	b.WriteIndentedString(4, "if (elem == null) {\n")
+	b.WriteIndentedString(5, "array.append(\"NULL\");\n")
+	b.WriteIndentedString(4, "} else {\n")
+	b.WriteIndentedString(5, "array.append('\"').append(elem.toString().replace(\"\\\\\", \"\\\\\\\\\").replace(\"\\\"\", \"\\\\\\\"\")).append('\"');\n")
+	b.WriteIndentedString(4, "}\n")
+	b.WriteIndentedString(3, "}\n")
+	b.WriteIndentedString(3, "text = array.append('}').toString();\n")
+	b.WriteIndentedString(2, "} else {\n")
+	b.WriteIndentedString(3, "text = value.toString();\n")
+	b.WriteIndentedString(2, "}\n\n")
+	b.WriteIndentedString(2, "sb.append('\"').append(text.replace(\"\\\"\", \"\\\"\\\"\")).append('\"');\n")
+	b.WriteIndentedString(1, "}\n\n")
+	b.WriteIndentedString(1, "@FunctionalInterface\n")
+	b.WriteIndentedString(1, "private interface CopyRowWriter<T> {\n")
+	b.WriteIndentedString(2, "void write("+core.Annotate("StringBuilder", nonNullAnnotation)+" line, T row);\n")
+	b.WriteIndentedString(1, "}\n\n")
+
+	b.WriteIndentedString(1, fmt.Sprintf(
+		"private static %s unwrapPgConnection(%s conn) throws SQLException {\n",
+		core.Annotate("Object", nullableAnnotation),
+		core.Annotate("java.sql.Connection", nonNullAnnotation),
+	))
+	b.WriteIndentedString(2, "Class<?> pgConnection;\n")
+	b.WriteIndentedString(2, "try {\n")
+	b.WriteIndentedString(3, "pgConnection = Class.forName(\"org.postgresql.PGConnection\");\n")
+	b.WriteIndentedString(2, "} catch (ClassNotFoundException e) {\n")
+	b.WriteIndentedString(3, "return null;\n")
+	b.WriteIndentedString(2, "}\n")
+	b.WriteIndentedString(2, "return conn.isWrapperFor(pgConnection) ? conn.unwrap(pgConnection) : null;\n")
+	b.WriteIndentedString(1, "}\n\n")
+
+	b.WriteIndentedString(1, fmt.Sprintf(
+		"private static <T> long copyIn(%s pgConn, %s sql, %s rows, %s writer) throws SQLException {\n",
+		core.Annotate("Object", nonNullAnnotation),
+		core.Annotate("String", nonNullAnnotation),
+		core.Annotate("Iterable<T>", nonNullAnnotation),
+		core.Annotate("CopyRowWriter<T>", nonNullAnnotation),
+	))
+	b.WriteIndentedString(2, "try {\n")
+	b.WriteIndentedString(3, "var loader = pgConn.getClass().getClassLoader();\n")
+	b.WriteIndentedString(3, "var copyManager = Class.forName(\"org.postgresql.PGConnection\", false, loader).getMethod(\"getCopyAPI\").invoke(pgConn);\n")
+	b.WriteIndentedString(3, "var copyIn = Class.forName(\"org.postgresql.copy.CopyManager\", false, loader).getMethod(\"copyIn\", String.class).invoke(copyManager, sql);\n")
+	b.WriteIndentedString(3, "var copyInType = Class.forName(\"org.postgresql.copy.CopyIn\", false, loader);\n")
+	b.WriteIndentedString(3, "var writeToCopy = copyInType.getMethod(\"writeToCopy\", byte[].class, int.class, int.class);\n")
+	b.WriteIndentedString(3, "try {\n")
+	b.WriteIndentedString(4, "var line = new StringBuilder();\n")
+	b.WriteIndentedString(4, "for (var row : rows) {\n")
+	b.WriteIndentedString(5, "line.setLength(0);\n")
+	b.WriteIndentedString(5, "writer.write(line, row);\n")
+	b.WriteIndentedString(5, "line.append('\\n');\n\n")
+	b.WriteIndentedString(5, "var bytes = line.toString().getBytes(java.nio.charset.StandardCharsets.UTF_8);\n")
+	b.WriteIndentedString(5, "writeToCopy.invoke(copyIn, bytes, 0, bytes.length);\n")
+	b.WriteIndentedString(4, "}\n\n")
+	b.WriteIndentedString(4, "return (long) copyInType.getMethod(\"endCopy\").invoke(copyIn);\n")
+	b.WriteIndentedString(3, "} finally {\n")
+	b.WriteIndentedString(4, "if ((boolean) copyInType.getMethod(\"isActive\").invoke(copyIn)) {\n")
+	b.WriteIndentedString(5, "copyInType.getMethod(\"cancelCopy\").invoke(copyIn);\n")
+	b.WriteIndentedString(4, "}\n")
+	b.WriteIndentedString(3, "}\n")
+	b.WriteIndentedString(2, "} catch (java.lang.reflect.InvocationTargetException e) {\n")
+	b.WriteIndentedString(3, "if (e.getCause() instanceof SQLException) throw (SQLException) e.getCause();\n")
+	b.WriteIndentedString(3, "throw new SQLException(\"COPY FROM STDIN failed\", e.getCause());\n")
+	b.WriteIndentedString(2, "} catch (ReflectiveOperationException e) {\n")
+	b.WriteIndentedString(3, "throw new SQLException(\"the pgjdbc copy API is not available\", e);\n")
+	b.WriteIndentedString(2, "}\n")
+	b.WriteIndentedString(1, "}\n")
+}
 
-	b.WriteIndentedString(2, newType+" "+name)
-	return imports, nil
+// writeBatchInsertHelpers writes the methods used when inserting the rows of :copyfrom queries using JDBC batches.
+func (b *IndentStringBuilder) writeBatchInsertHelpers() {
+	b.WriteString("\n")
+	b.WriteIndentedString(1, "private static final int COPY_BATCH_SIZE = 1000;\n\n")
+	b.WriteIndentedString(1, "private static long countUpdated(int[] counts) {\n")
+	b.WriteIndentedString(2, "var updated = 0L;\n")
+	b.WriteIndentedString(2, "for (var count : counts) {\n")
+	b.WriteIndentedString(3, "// drivers may not report the affected row count for batched statements\n")
+	b.WriteIndentedString(3, "updated += count == java.sql.Statement.SUCCESS_NO_INFO ? 1 : count;\n")
+	b.WriteIndentedString(2, "}\n")
+	b.WriteIndentedString(2, "return updated;\n")
+	b.WriteIndentedString(1, "}\n")
 }
@@ -6,8 +6,6 @@ import (
 	"github.com/tandemdude/sqlc-gen-java/internal/core"
 	"regexp"
 	"strings"
-	"unicode"
-	"unicode/utf8"
 )
 
 var javaInvalidIdentChars = regexp.MustCompile("[^$\\w]")
@@ -19,14 +17,7 @@ func EnumClassName(qualifiedName, defaultSchema string) string {
 func enumValueName(value string) string {
 	rep := strings.NewReplacer("-", "_", ":", "_", "/", "_", ".", "_")
 	name := rep.Replace(value)
-	name = strings.ToUpper(name)
-	name = javaInvalidIdentChars.ReplaceAllString(name, "")
-
-	r, _ := utf8.DecodeRuneInString(name)
-	if unicode.IsDigit(r) {
-		name = "_" + name
-	}
-	return name
+	return JavaIdentifier(strings.ToUpper(name))
 }
 
 func BuildEnumFile(engine string, conf core.Config, qualName string, enum core.Enum, defaultSchema string) (string, []byte, error) {
@@ -0,0 +1,36 @@
+package codegen
+
+import (
+	"slices"
+	"unicode"
+	"unicode/utf8"
+)
+
+// javaReservedWords are the keywords and literals which cannot be used as identifiers.
+var javaReservedWords = []string{
+	"_", "abstract", "assert", "boolean", "break", "byte", "case", "catch", "char", "class", "const", "continue",
+	"default", "do", "double", "else", "enum", "extends", "false", "final", "finally", "float", "for", "goto", "if",
+	"implements", "import", "instanceof", "int", "interface", "long", "native", "new", "null", "package", "private",
+	"protected", "public", "return", "short", "static", "strictfp", "super", "switch", "synchronized", "this",
+	"throw", "throws", "transient", "true", "try", "void", "volatile", "while",
+}
+
+// javaObjectMethods are the no-argument methods declared by java.lang.Object. A record component with one of these
+// names would generate an accessor clashing with the inherited method.
+var javaObjectMethods = []string{"clone", "finalize", "getClass", "hashCode", "notify", "notifyAll", "toString", "wait"}
+
+// JavaIdentifier sanitises the given name so that it can be used as a Java identifier. Invalid characters are removed,
+// names starting with a digit are prefixed with an underscore, and names which are reserved are suffixed with one.
+func JavaIdentifier(name string) string {
+	name = javaInvalidIdentChars.ReplaceAllString(name, "")
+
+	r, _ := utf8.DecodeRuneInString(name)
+	if name == "" || unicode.IsDigit(r) {
+		name = "_" + name
+	}
+
+	if slices.Contains(javaReservedWords, name) || slices.Contains(javaObjectMethods, name) {
+		name += "_"
+	}
+	return name
+}
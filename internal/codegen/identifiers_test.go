@@ -0,0 +1,53 @@
+package codegen
+
+import "testing"
+
+func TestJavaIdentifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"userId", "userId"},
+		{"class", "class_"},
+		{"default", "default_"},
+		{"package", "package_"},
+		{"interface", "interface_"},
+		{"new", "new_"},
+		{"null", "null_"},
+		{"_", "__"},
+		{"hashCode", "hashCode_"},
+		{"toString", "toString_"},
+		{"Class", "Class"},
+		{"user-id", "userid"},
+		{"first name", "firstname"},
+		{"1st", "_1st"},
+		{"", "__"},
+		{"$value", "$value"},
+	}
+
+	for _, test := range tests {
+		if got := JavaIdentifier(test.name); got != test.expected {
+			t.Errorf("JavaIdentifier(%q) = %q, expected %q", test.name, got, test.expected)
+		}
+	}
+}
+
+func TestEnumValueName(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"active", "ACTIVE"},
+		{"in-progress", "IN_PROGRESS"},
+		{"a.b/c:d", "A_B_C_D"},
+		{"2fa", "_2FA"},
+		{"what?", "WHAT"},
+		{"_", "__"},
+	}
+
+	for _, test := range tests {
+		if got := enumValueName(test.value); got != test.expected {
+			t.Errorf("enumValueName(%q) = %q, expected %q", test.value, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package codegen
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/tandemdude/sqlc-gen-java/internal/core"
+)
+
+// javaLangTypes are the implicitly imported java.lang types referenced by generated code. They must not be shadowed
+// by an imported type with the same simple name.
+var javaLangTypes = []string{
+	"Boolean", "Character", "Class", "Double", "Float", "FunctionalInterface", "IllegalArgumentException",
+	"IllegalStateException", "Integer", "Iterable", "Long", "Number", "Object", "Override", "RuntimeException", "Short",
+	"String", "StringBuilder", "Void",
+}
+
+// importTracker resolves the name each type referenced by a generated file should be written as, and collects the
+// imports the file requires. Types are imported and referred to by their simple name where possible. If the simple
+// name is already taken - by a type declared in the same package, a java.lang type, or another imported type - the
+// fully qualified name is used instead.
+type importTracker struct {
+	pkg          string
+	packageTypes []string
+	// names maps each claimed simple name to the fully qualified name of the class it refers to
+	names map[string]string
+	used  map[string]bool
+}
+
+// newImportTracker creates a tracker for a file in the given package. The package types are the simple names of the
+// classes declared in the same package, including those nested within the file's own class.
+func newImportTracker(pkg string, packageTypes ...string) *importTracker {
+	return &importTracker{
+		pkg:          pkg,
+		packageTypes: packageTypes,
+		names:        make(map[string]string),
+		used:         make(map[string]bool),
+	}
+}
+
+// reserve claims the simple names of the given fully qualified types without importing them, so that they are
+// preferred over any other type with the same simple name referenced later.
+func (t *importTracker) reserve(types ...string) {
+	for _, typ := range types {
+		imp, _, err := core.ResolveImportAndType(typ)
+		if err != nil || imp == "" {
+			continue
+		}
+
+		simpleName := imp[strings.LastIndex(imp, ".")+1:]
+		if _, ok := t.names[simpleName]; !ok && t.canClaim(simpleName) {
+			t.names[simpleName] = imp
+		}
+	}
+}
+
+func (t *importTracker) canClaim(simpleName string) bool {
+	return !slices.Contains(t.packageTypes, simpleName) && !slices.Contains(javaLangTypes, simpleName)
+}
+
+// Type returns the name the given java type should be referred to by, importing it if required.
+func (t *importTracker) Type(typ string) string {
+	if !strings.Contains(typ, ".") {
+		// a type declared in the same package shadows the implicitly imported java.lang type
+		if slices.Contains(javaLangTypes, typ) && slices.Contains(t.packageTypes, typ) {
+			return "java.lang." + typ
+		}
+		return typ
+	}
+
+	imp, name, err := core.ResolveImportAndType(typ)
+	if err != nil {
+		// the fully qualified name can always be used as-is
+		return typ
+	}
+	if imp == "" {
+		// nested class in the same package, no import required
+		return name
+	}
+
+	simpleName := imp[strings.LastIndex(imp, ".")+1:]
+	if imp[:strings.LastIndex(imp, ".")] == t.pkg {
+		// types declared in the same package are always in scope
+		return name
+	}
+	if existing, ok := t.names[simpleName]; ok {
+		if existing != imp {
+			return typ
+		}
+	} else {
+		if !t.canClaim(simpleName) {
+			return typ
+		}
+		t.names[simpleName] = imp
+	}
+
+	t.used[imp] = true
+	return name
+}
+
+// Annotation returns the usage of the given fully qualified annotation type, e.g. "@Nullable", importing it if
+// required. An empty string is returned if no annotation is given.
+func (t *importTracker) Annotation(typ string) string {
+	if typ == "" {
+		return ""
+	}
+	return "@" + t.Type(typ)
+}
+
+// Imports returns the sorted imports required by the types referenced so far.
+func (t *importTracker) Imports() []string {
+	imports := make([]string, 0, len(t.used))
+	for imp := range t.used {
+		// types in the same package or java.lang never need to be imported
+		pkg := imp[:strings.LastIndex(imp, ".")]
+		if pkg == t.pkg || pkg == "java.lang" {
+			continue
+		}
+		imports = append(imports, imp)
+	}
+
+	slices.Sort(imports)
+	return imports
+}
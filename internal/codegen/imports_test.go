@@ -0,0 +1,89 @@
+package codegen
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tandemdude/sqlc-gen-java/internal/core"
+)
+
+func TestImportTrackerFallsBackToQualifiedNameOnCollision(t *testing.T) {
+	tracker := newImportTracker("com.example")
+
+	if got := tracker.Type("com.example.models.Status"); got != "Status" {
+		t.Errorf("expected first type to be imported, got %s", got)
+	}
+	if got := tracker.Type("com.example.enums.Status"); got != "com.example.enums.Status" {
+		t.Errorf("expected colliding type to be fully qualified, got %s", got)
+	}
+	// the first type must keep using the simple name
+	if got := tracker.Type("com.example.models.Status"); got != "Status" {
+		t.Errorf("expected first type to keep its simple name, got %s", got)
+	}
+
+	if imports := tracker.Imports(); !slices.Equal(imports, []string{"com.example.models.Status"}) {
+		t.Errorf("unexpected imports %v", imports)
+	}
+}
+
+func TestImportTrackerPrefersReservedTypes(t *testing.T) {
+	tracker := newImportTracker("com.example")
+	tracker.reserve("java.util.List")
+
+	if got := tracker.Type("com.example.models.List"); got != "com.example.models.List" {
+		t.Errorf("expected type colliding with reserved type to be fully qualified, got %s", got)
+	}
+	if got := tracker.Type("java.util.List"); got != "List" {
+		t.Errorf("expected reserved type to be imported, got %s", got)
+	}
+	if imports := tracker.Imports(); !slices.Equal(imports, []string{"java.util.List"}) {
+		t.Errorf("unexpected imports %v", imports)
+	}
+}
+
+func TestImportTrackerPackageTypesShadowImports(t *testing.T) {
+	tracker := newImportTracker("com.example.models", "Date", "String")
+
+	if got := tracker.Type("java.sql.Date"); got != "java.sql.Date" {
+		t.Errorf("expected type shadowed by package type to be fully qualified, got %s", got)
+	}
+	if got := tracker.Type("String"); got != "java.lang.String" {
+		t.Errorf("expected shadowed java.lang type to be fully qualified, got %s", got)
+	}
+	if got := tracker.Type("com.example.models.Date"); got != "Date" {
+		t.Errorf("expected type in same package to use simple name, got %s", got)
+	}
+	if imports := tracker.Imports(); len(imports) != 0 {
+		t.Errorf("expected no imports, got %v", imports)
+	}
+}
+
+func TestImportTrackerNeverShadowsJavaLang(t *testing.T) {
+	tracker := newImportTracker("com.example")
+
+	if got := tracker.Type("com.example.models.String"); got != "com.example.models.String" {
+		t.Errorf("expected type colliding with java.lang type to be fully qualified, got %s", got)
+	}
+	if got := tracker.Type("String"); got != "String" {
+		t.Errorf("expected java.lang type to use simple name, got %s", got)
+	}
+}
+
+func TestModelFileQualifiesShadowedTypes(t *testing.T) {
+	model := []core.QueryReturn{
+		{Name: "day", JavaType: core.JavaType{SqlType: "date", Type: "java.sql.Date"}},
+		{Name: "label", JavaType: core.JavaType{SqlType: "text", Type: "String"}},
+	}
+
+	_, contents, err := BuildModelFile(testConfig, "event", model, []string{"Date", "Event"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(contents)
+	if strings.Contains(out, "import java.sql.Date;") {
+		t.Errorf("expected shadowed type not to be imported:\n%s", out)
+	}
+	assertInOrder(t, out, []string{"java.sql.@NonNull Date day", "@NonNull String label"})
+}
@@ -2,65 +2,272 @@ package codegen
 
 import (
 	"fmt"
-	"slices"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/iancoleman/strcase"
 	"github.com/tandemdude/sqlc-gen-java/internal/core"
 )
 
-func BuildModelFile(config core.Config, name string, model []core.QueryReturn) (string, []byte, error) {
-	imports := make([]string, 0)
-
-	var nonNullAnnotation string
-	if config.NonNullAnnotation != "" {
-		imports = append(imports, config.NonNullAnnotation)
-		nonNullAnnotation = "@" + config.NonNullAnnotation[strings.LastIndex(config.NonNullAnnotation, ".")+1:]
-	}
-	var nullableAnnotation string
-	if config.NullableAnnotation != "" {
-		imports = append(imports, config.NullableAnnotation)
-		nullableAnnotation = "@" + config.NullableAnnotation[strings.LastIndex(config.NullableAnnotation, ".")+1:]
+// BuildModelFile generates the record, or plain class, for the given model. The package types are the simple names of
+// the other models, which are declared in the same package and so shadow any imported types with the same name.
+func BuildModelFile(config core.Config, name string, model []core.QueryReturn, packageTypes []string) (string, []byte, error) {
+	className := strcase.ToCamel(name)
+	if config.EmitBuilders && className == "Builder" {
+		return "", nil, fmt.Errorf("model %s conflicts with the nested Builder class generated by emit_builders", className)
 	}
 
+	// the nested Builder class shadows any imported type with the same name
+	t := newImportTracker(config.Package+".models", append([]string{className, "Builder"}, packageTypes...)...)
+	t.reserve("javax.annotation.processing.Generated")
+	nonNullAnnotation := t.Annotation(config.NonNullAnnotation)
+	nullableAnnotation := t.Annotation(config.NullableAnnotation)
+
 	header := NewIndentStringBuilder(config.IndentChar, config.CharsPerIndentLevel)
 	header.writeSqlcHeader()
 	header.WriteString("\n")
 	header.WriteString("package " + config.Package + ".models;\n")
 	header.WriteString("\n")
-	header.WriteString("import javax.annotation.processing.Generated;\n")
-	header.WriteString("\n")
 
 	body := NewIndentStringBuilder(config.IndentChar, config.CharsPerIndentLevel)
 	body.WriteString("\n")
-	body.WriteString("@Generated(\"io.github.tandemdude.sqlc-gen-java\")\n")
-	body.WriteString("public record " + strcase.ToCamel(name) + "(\n")
-	for i, ret := range model {
-		imps, err := body.writeParameter(ret.JavaType, ret.Name, nonNullAnnotation, nullableAnnotation)
-		if err != nil {
-			return "", nil, err
+	body.WriteString("@" + t.Type("javax.annotation.processing.Generated") + "(\"io.github.tandemdude.sqlc-gen-java\")\n")
+	if config.ModelStyle == core.ModelStyleClass {
+		body.writeValueClass(0, "public", className, model, config.EmitBuilders, t, nonNullAnnotation, nullableAnnotation)
+	} else {
+		body.WriteString("public record " + className + "(\n")
+		for i, ret := range model {
+			body.writeParameter(ret.JavaType, ret.Name, t, nonNullAnnotation, nullableAnnotation)
+
+			if i != len(model)-1 {
+				body.WriteString(",\n")
+			}
 		}
-		if imps != nil {
-			imports = append(imports, imps...)
+		body.WriteString("\n")
+
+		if config.EmitBuilders {
+			body.WriteString(") {\n")
+			body.writeBuilders(0, className, model, t, nonNullAnnotation, nullableAnnotation)
+			body.WriteString("}\n")
+		} else {
+			body.WriteString(") {}\n")
 		}
+	}
+
+	writeImports(header, t)
+
+	return fmt.Sprintf("models/%s.java", className), []byte(header.String() + body.String()), nil
+}
+
+// getterName returns the name of the getter generated for the given field of a plain class.
+func getterName(field string) string {
+	r, size := utf8.DecodeRuneInString(field)
+	return "get" + string(unicode.ToUpper(r)) + field[size:]
+}
+
+// fieldEquals returns the condition comparing the given field of "this" and "that", which has the given declared
+// type. Values are compared in the same way as the components of a record.
+func fieldEquals(field, declaredType string, t *importTracker) string {
+	switch declaredType {
+	case "boolean", "byte", "char", "short", "int", "long":
+		return "this." + field + " == that." + field
+	case "float":
+		return t.Type("Float") + ".compare(this." + field + ", that." + field + ") == 0"
+	case "double":
+		return t.Type("Double") + ".compare(this." + field + ", that." + field + ") == 0"
+	default:
+		return t.Type("java.util.Objects") + ".equals(this." + field + ", that." + field + ")"
+	}
+}
+
+// writeValueClass writes a final class at the given indent level, equivalent to a record with the given components,
+// for Java versions without record support. Each component is exposed using a getter, and equals, hashCode and
+// toString behave the same as those of a record. Builders are also generated if configured.
+func (b *IndentStringBuilder) writeValueClass(level int, modifiers, name string, fields []core.QueryReturn, builders bool, t *importTracker, nonNullAnnotation, nullableAnnotation string) {
+	types := make([]string, 0, len(fields))
+	for _, field := range fields {
+		types = append(types, parameterType(field.JavaType, t, nonNullAnnotation, nullableAnnotation))
+	}
 
-		if i != len(model)-1 {
-			body.WriteString(",\n")
+	b.WriteIndentedString(level, modifiers+" final class "+name+" {\n")
+	for i, field := range fields {
+		b.WriteIndentedString(level+1, "private final "+types[i]+" "+field.Name+";\n")
+	}
+
+	b.WriteString("\n")
+	if len(fields) == 0 {
+		b.WriteIndentedString(level+1, "public "+name+"() {\n")
+	} else {
+		b.WriteIndentedString(level+1, "public "+name+"(\n")
+		for i, field := range fields {
+			b.WriteIndentedString(level+2, types[i]+" "+field.Name)
+
+			if i != len(fields)-1 {
+				b.WriteString(",\n")
+			}
 		}
+		b.WriteString("\n")
+		b.WriteIndentedString(level+1, ") {\n")
 	}
-	body.WriteString("\n")
-	body.WriteString(") {}\n")
+	for _, field := range fields {
+		b.WriteIndentedString(level+2, "this."+field.Name+" = "+field.Name+";\n")
+	}
+	b.WriteIndentedString(level+1, "}\n")
+
+	for i, field := range fields {
+		b.WriteString("\n")
+		b.WriteIndentedString(level+1, "public "+types[i]+" "+getterName(field.Name)+"() {\n")
+		b.WriteIndentedString(level+2, "return this."+field.Name+";\n")
+		b.WriteIndentedString(level+1, "}\n")
+	}
+
+	override := "@" + t.Type("Override") + "\n"
 
-	// sort alphabetically and remove duplicate imports
-	slices.Sort(imports)
-	imports = slices.Compact(imports)
-	for _, imp := range imports {
-		if imp == "" {
+	b.WriteString("\n")
+	b.WriteIndentedString(level+1, override)
+	b.WriteIndentedString(level+1, "public boolean equals("+core.Annotate(t.Type("Object"), nullableAnnotation)+" o) {\n")
+	b.WriteIndentedString(level+2, "if (this == o) return true;\n")
+	b.WriteIndentedString(level+2, "if (!(o instanceof "+name+")) return false;\n\n")
+	if len(fields) == 0 {
+		b.WriteIndentedString(level+2, "return true;\n")
+	} else {
+		b.WriteIndentedString(level+2, "var that = ("+name+") o;\n")
+	}
+	for i, field := range fields {
+		if i == 0 {
+			b.WriteIndentedString(level+2, "return "+fieldEquals(field.Name, types[i], t))
 			continue
 		}
+		b.WriteString("\n")
+		b.WriteIndentedString(level+3, "&& "+fieldEquals(field.Name, types[i], t))
+	}
+	if len(fields) != 0 {
+		b.WriteString(";\n")
+	}
+	b.WriteIndentedString(level+1, "}\n")
+
+	values := make([]string, 0, len(fields))
+	for _, field := range fields {
+		values = append(values, "this."+field.Name)
+	}
+
+	b.WriteString("\n")
+	b.WriteIndentedString(level+1, override)
+	b.WriteIndentedString(level+1, "public int hashCode() {\n")
+	if len(fields) == 0 {
+		b.WriteIndentedString(level+2, "return 0;\n")
+	} else {
+		b.WriteIndentedString(level+2, "return "+t.Type("java.util.Objects")+".hash("+strings.Join(values, ", ")+");\n")
+	}
+	b.WriteIndentedString(level+1, "}\n")
+
+	b.WriteString("\n")
+	b.WriteIndentedString(level+1, override)
+	b.WriteIndentedString(level+1, "public "+t.Type("String")+" toString() {\n")
+	if len(fields) == 0 {
+		b.WriteIndentedString(level+2, "return \""+name+"[]\";\n")
+	} else {
+		b.WriteIndentedString(level+2, "return \""+name+"[")
+		for i, field := range fields {
+			if i != 0 {
+				b.WriteString(" + \", ")
+			}
+			b.WriteString(field.Name + "=\" + this." + field.Name)
+		}
+		b.WriteString(" + \"]\";\n")
+	}
+	b.WriteIndentedString(level+1, "}\n")
+
+	if builders {
+		b.WriteString("\n")
+		b.writeBuilders(level, name, fields, t, nonNullAnnotation, nullableAnnotation)
+	}
+	b.WriteIndentedString(level, "}\n")
+}
+
+// witherName returns the name of the copy method replacing the given field.
+func witherName(field string) string {
+	r, size := utf8.DecodeRuneInString(field)
+	return "with" + string(unicode.ToUpper(r)) + field[size:]
+}
+
+// writeConstruction writes the construction of the named class from the given values, followed by the given suffix.
+func (b *IndentStringBuilder) writeConstruction(level int, name string, values []string, suffix string) {
+	b.WriteIndentedString(level, "return new "+name+"(\n")
+	for i, value := range values {
+		b.WriteIndentedString(level+1, value)
+
+		if i != len(values)-1 {
+			b.WriteString(",\n")
+		}
+	}
+	b.WriteString("\n")
+	b.WriteIndentedString(level, ")"+suffix)
+}
+
+// writeBuilders writes the members of the named record or plain class, declared at the given indent level, allowing
+// instances to be built and copied: a nested static Builder class, a toBuilder method returning a builder populated
+// with the instance's values, and a withXxx method for each field returning a copy with only that value replaced.
+func (b *IndentStringBuilder) writeBuilders(level int, name string, fields []core.QueryReturn, t *importTracker, nonNullAnnotation, nullableAnnotation string) {
+	types := make([]string, 0, len(fields))
+	for _, field := range fields {
+		types = append(types, parameterType(field.JavaType, t, nonNullAnnotation, nullableAnnotation))
+	}
+	builderType := core.Annotate("Builder", nonNullAnnotation)
+
+	b.WriteIndentedString(level+1, "public "+builderType+" toBuilder() {\n")
+	b.WriteIndentedString(level+2, "return new Builder()")
+	for _, field := range fields {
+		b.WriteString("\n")
+		b.WriteIndentedString(level+3, "."+field.Name+"(this."+field.Name+")")
+	}
+	b.WriteString(";\n")
+	b.WriteIndentedString(level+1, "}\n")
+
+	for i, field := range fields {
+		values := make([]string, 0, len(fields))
+		for _, other := range fields {
+			if other.Name == field.Name {
+				values = append(values, field.Name)
+			} else {
+				values = append(values, "this."+other.Name)
+			}
+		}
+
+		b.WriteString("\n")
+		b.WriteIndentedString(level+1, "public "+core.Annotate(name, nonNullAnnotation)+" "+witherName(field.Name)+"("+types[i]+" "+field.Name+") {\n")
+		b.writeConstruction(level+2, name, values, ";\n")
+		b.WriteIndentedString(level+1, "}\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteIndentedString(level+1, "public static final class Builder {\n")
+	values := make([]string, 0, len(fields))
+	for _, field := range fields {
+		// values are null until the setter is called, primitives included, so non-null fields are checked once the
+		// instance is built
+		nullableType := field.JavaType
+		nullableType.IsNullable = true
+		b.WriteIndentedString(level+2, "private "+parameterType(nullableType, t, nonNullAnnotation, nullableAnnotation)+" "+field.Name+";\n")
+		if field.JavaType.IsNullable {
+			values = append(values, "this."+field.Name)
+		} else {
+			values = append(values, t.Type("java.util.Objects")+".requireNonNull(this."+field.Name+", \""+field.Name+"\")")
+		}
+	}
 
-		header.WriteString("import " + imp + ";\n")
+	for i, field := range fields {
+		b.WriteString("\n")
+		b.WriteIndentedString(level+2, "public "+builderType+" "+field.Name+"("+types[i]+" "+field.Name+") {\n")
+		b.WriteIndentedString(level+3, "this."+field.Name+" = "+field.Name+";\n")
+		b.WriteIndentedString(level+3, "return this;\n")
+		b.WriteIndentedString(level+2, "}\n")
 	}
 
-	return fmt.Sprintf("models/%s.java", strcase.ToCamel(name)), []byte(header.String() + body.String()), nil
+	b.WriteString("\n")
+	b.WriteIndentedString(level+2, "public "+core.Annotate(name, nonNullAnnotation)+" build() {\n")
+	b.writeConstruction(level+3, name, values, ";\n")
+	b.WriteIndentedString(level+2, "}\n")
+	b.WriteIndentedString(level+1, "}\n")
 }
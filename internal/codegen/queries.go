@@ -1,9 +1,9 @@
 package codegen
 
 import (
-	"errors"
 	"fmt"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/iancoleman/strcase"
@@ -14,13 +14,163 @@ func resultRecordName(q core.Query) string {
 	return strcase.ToCamel(q.MethodName) + "Row"
 }
 
-func createEmbeddedModel(sb *IndentStringBuilder, prefix, suffix string, identLevel, paramIdx int, r core.QueryReturn, embeddedModels core.EmbeddedModels) int {
+func paramsRecordName(q core.Query) string {
+	return strcase.ToCamel(q.MethodName) + "Params"
+}
+
+// paramsRecordFields converts the query's arguments into the components of its params record.
+func paramsRecordFields(q core.Query) []core.QueryReturn {
+	fields := make([]core.QueryReturn, 0, len(q.Args))
+	for _, arg := range q.Args {
+		fields = append(fields, core.QueryReturn{Name: arg.Name, JavaType: arg.JavaType})
+	}
+	return fields
+}
+
+func copyStatementName(q core.Query) string {
+	return q.MethodName + "Copy"
+}
+
+func copyStatement(q core.Query) string {
+	columns := make([]string, 0, len(q.Args))
+	for _, arg := range q.Args {
+		columns = append(columns, arg.Column)
+	}
+	return fmt.Sprintf("COPY %s (%s) FROM STDIN (FORMAT csv)", q.InsertIntoTable, strings.Join(columns, ", "))
+}
+
+// writeNestedRecord writes a record class nested inside the queries class, or an equivalent plain class if configured.
+// Builders are generated for the record if requested.
+func (b *IndentStringBuilder) writeNestedRecord(config core.Config, name string, fields []core.QueryReturn, builders bool, t *importTracker, nonNullAnnotation, nullableAnnotation string) {
+	b.WriteString("\n")
+	if config.ModelStyle == core.ModelStyleClass {
+		b.writeValueClass(1, "public static", name, fields, builders, t, nonNullAnnotation, nullableAnnotation)
+		return
+	}
+
+	b.WriteIndentedString(1, "public record "+name+"(\n")
+	for i, field := range fields {
+		b.writeParameter(field.JavaType, field.Name, t, nonNullAnnotation, nullableAnnotation)
+
+		if i != len(fields)-1 {
+			b.WriteString(",\n")
+		}
+	}
+	b.WriteString("\n")
+
+	if !builders {
+		b.WriteIndentedString(1, ") {}\n")
+		return
+	}
+	b.WriteIndentedString(1, ") {\n")
+	b.writeBuilders(1, name, fields, t, nonNullAnnotation, nullableAnnotation)
+	b.WriteIndentedString(1, "}\n")
+}
+
+// javaStringLiteral quotes the given text as a java string literal.
+func javaStringLiteral(text string) string {
+	return "\"" + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\t", `\t`, "\r", `\r`, "\n", `\n`).Replace(text) + "\""
+}
+
+// writeQueryText writes the static attribute containing the query string.
+func (b *IndentStringBuilder) writeQueryText(config core.Config, q core.Query) {
+	lines := []string{"-- name: " + q.RawQueryName + " " + q.RawCommand}
+	// split the query into lines, so that each is indented correctly
+	for _, part := range strings.Split(q.Text, "\n") {
+		if part == "" {
+			continue
+		}
+		lines = append(lines, part)
+	}
+
+	b.writeStringConstant(config, q.MethodName, lines)
+}
+
+// writeStringConstant writes a static attribute containing the given lines of text. Text blocks are not supported by
+// the Java versions targeted by plain class models, so the lines are instead written as concatenated string literals.
+func (b *IndentStringBuilder) writeStringConstant(config core.Config, name string, lines []string) {
+	if config.ModelStyle == core.ModelStyleClass {
+		b.WriteIndentedString(1, "private static final String "+name+" =\n")
+		for i, line := range lines {
+			// text blocks strip trailing whitespace from each line
+			b.WriteIndentedString(2, javaStringLiteral(strings.TrimRight(line, " \t")+"\n"))
+
+			if i != len(lines)-1 {
+				b.WriteString(" +\n")
+			}
+		}
+		b.WriteString(";\n")
+		return
+	}
+
+	b.WriteIndentedString(1, "private static final String "+name+" = \"\"\"\n")
+	for _, line := range lines {
+		b.WriteIndentedString(2, line+"\n")
+	}
+	b.WriteIndentedString(2, "\"\"\";\n")
+}
+
+// writeQueryRecords writes the output and input record classes used by the method generated for the given query.
+func (b *IndentStringBuilder) writeQueryRecords(config core.Config, q core.Query, t *importTracker, nonNullAnnotation, nullableAnnotation string) {
+	if len(q.Returns) > 1 {
+		b.writeNestedRecord(config, resultRecordName(q), q.Returns, config.EmitBuilders, t, nonNullAnnotation, nullableAnnotation)
+	}
+
+	if q.Command.TakesParamsList() || q.UseParamsRecord {
+		b.writeNestedRecord(config, paramsRecordName(q), paramsRecordFields(q), false, t, nonNullAnnotation, nullableAnnotation)
+	}
+}
+
+// columnReader generates the expressions used to read the columns of each row returned by a query. Column numbers
+// start from 1, regardless of the indexing used by the underlying API.
+type columnReader interface {
+	// value returns the expression reading the given return value from the column with the given number. The type
+	// name is the name the return type is referred to by within the generated file.
+	value(ret core.QueryReturn, number int, typeName string) string
+	// isNull returns the condition checking whether the column with the given number is null.
+	isNull(number int) string
+}
+
+// jdbcColumns reads the columns of the current row of a JDBC ResultSet named "results" for the given engine.
+type jdbcColumns string
+
+func (engine jdbcColumns) value(ret core.QueryReturn, number int, typeName string) string {
+	return ret.ResultStmt(string(engine), number, typeName)
+}
+
+func (jdbcColumns) isNull(number int) string {
+	return fmt.Sprintf("results.getObject(%d) == null", number)
+}
+
+// embeddedRowCheck returns the condition used to determine whether the columns of a nullable embedded model, starting
+// at the given column index, are all null due to an outer join. Only a single column needs to be checked if the model
+// has a non-null column, e.g. the primary key.
+func embeddedRowCheck(columns columnReader, model []core.QueryReturn, paramIdx int) string {
+	for i, ret := range model {
+		if !ret.JavaType.IsNullable {
+			return columns.isNull(paramIdx + i)
+		}
+	}
+
+	checks := make([]string, 0, len(model))
+	for i := range model {
+		checks = append(checks, columns.isNull(paramIdx+i))
+	}
+	return strings.Join(checks, " && ")
+}
+
+func createEmbeddedModel(sb *IndentStringBuilder, columns columnReader, prefix, suffix string, identLevel, paramIdx int, r core.QueryReturn, embeddedModels core.EmbeddedModels, t *importTracker) int {
 	modelName := *r.EmbeddedModel
 	model := embeddedModels[modelName]
 
-	sb.WriteIndentedString(identLevel, prefix+modelName+"(\n")
+	// the model is only constructed if the outer join produced a matching row
+	if r.JavaType.IsNullable {
+		prefix += embeddedRowCheck(columns, model, paramIdx) + " ? null : "
+	}
+
+	sb.WriteIndentedString(identLevel, prefix+"new "+t.Type(r.JavaType.Type)+"(\n")
 	for i, ret := range model {
-		sb.WriteIndentedString(identLevel+1, ret.ResultStmt(paramIdx))
+		sb.WriteIndentedString(identLevel+1, columns.value(ret, paramIdx, t.Type(ret.JavaType.Type)))
 
 		if i != len(model)-1 {
 			sb.WriteString(",\n")
@@ -33,17 +183,17 @@ func createEmbeddedModel(sb *IndentStringBuilder, prefix, suffix string, identLe
 	return paramIdx
 }
 
-func createResultRecord(sb *IndentStringBuilder, indentLevel int, q core.Query, embeddedModels core.EmbeddedModels) {
+func createResultRecord(sb *IndentStringBuilder, columns columnReader, indentLevel int, q core.Query, embeddedModels core.EmbeddedModels, t *importTracker) {
 	paramIdx := 1
 
 	if len(q.Returns) == 1 {
 		// set ret to the item directly instead of wrapping it in the result record
 		if q.Returns[0].EmbeddedModel != nil {
-			createEmbeddedModel(sb, "var ret = new ", ");\n", indentLevel, paramIdx, q.Returns[0], embeddedModels)
+			createEmbeddedModel(sb, columns, "var ret = ", ");\n", indentLevel, paramIdx, q.Returns[0], embeddedModels, t)
 			return
 		}
 
-		sb.WriteIndentedString(indentLevel, "var ret = "+q.Returns[0].ResultStmt(1)+";\n")
+		sb.WriteIndentedString(indentLevel, "var ret = "+columns.value(q.Returns[0], 1, t.Type(q.Returns[0].JavaType.Type))+";\n")
 		return
 	}
 
@@ -52,9 +202,9 @@ func createResultRecord(sb *IndentStringBuilder, indentLevel int, q core.Query,
 	for i, ret := range q.Returns {
 		// if this return is an embedded model we need to do a lil bit extra
 		if ret.EmbeddedModel != nil {
-			paramIdx = createEmbeddedModel(sb, "new ", ")", indentLevel+1, paramIdx, ret, embeddedModels)
+			paramIdx = createEmbeddedModel(sb, columns, "", ")", indentLevel+1, paramIdx, ret, embeddedModels, t)
 		} else {
-			sb.WriteIndentedString(indentLevel+1, ret.ResultStmt(paramIdx))
+			sb.WriteIndentedString(indentLevel+1, columns.value(ret, paramIdx, t.Type(ret.JavaType.Type)))
 		}
 
 		if i != len(q.Returns)-1 {
@@ -67,229 +217,882 @@ func createResultRecord(sb *IndentStringBuilder, indentLevel int, q core.Query,
 	sb.WriteIndentedString(indentLevel, ");\n")
 }
 
-func completeMethodBody(sb *IndentStringBuilder, q core.Query, embeddedModels core.EmbeddedModels) {
-	sb.WriteString("\n")
+// optionalOf returns the method used to wrap the single row returned by a query in an Optional.
+func optionalOf(q core.Query, t *importTracker) string {
+	if len(q.Returns) == 1 && q.Returns[0].JavaType.IsNullable {
+		return t.Type("java.util.Optional") + ".ofNullable"
+	}
+	return t.Type("java.util.Optional") + ".of"
+}
 
-	switch q.Command {
-	case core.One, core.Many:
-		sb.WriteIndentedString(2, "var results = stmt.executeQuery();\n")
-	case core.Exec, core.ExecRows, core.ExecResult:
-		sb.WriteIndentedString(2, "stmt.execute();\n")
-	default:
-		sb.WriteIndentedString(2, "// TODO\n")
+// argValue returns the expression used to access the value of the given argument within the method body.
+func argValue(config core.Config, q core.Query, arg core.QueryArg) string {
+	accessor := arg.Name + "()"
+	// plain class params records are read using getters
+	if config.ModelStyle == core.ModelStyleClass {
+		accessor = getterName(arg.Name) + "()"
+	}
+
+	if q.Command.TakesParamsList() {
+		return "row." + accessor
+	}
+	if q.UseParamsRecord {
+		return "params." + accessor
+	}
+	return arg.Name
+}
+
+// argBinding is an argument bound to a JDBC parameter index.
+type argBinding struct {
+	Index int
+	Arg   core.QueryArg
+}
+
+// argBindings returns the argument to bind to each JDBC parameter index. Arguments referenced multiple times by the
+// query are bound once per reference.
+func argBindings(q core.Query) []argBinding {
+	bindings := make([]argBinding, 0, len(q.Args))
+	if q.Placeholders == nil {
+		for _, arg := range q.Args {
+			bindings = append(bindings, argBinding{arg.Number, arg})
+		}
+		return bindings
+	}
+
+	for i, number := range q.Placeholders {
+		idx := slices.IndexFunc(q.Args, func(arg core.QueryArg) bool { return arg.Number == number })
+		if idx == -1 {
+			continue
+		}
+		bindings = append(bindings, argBinding{i + 1, q.Args[idx]})
+	}
+	return bindings
+}
+
+func hasSliceArgs(q core.Query) bool {
+	return slices.ContainsFunc(q.Args, func(arg core.QueryArg) bool { return arg.IsSlice })
+}
+
+// sliceMarker returns the placeholder sqlc generates for the given sqlc.slice argument.
+func sliceMarker(arg core.QueryArg) string {
+	return "/*SLICE:" + arg.Column + "*/?"
+}
+
+// expandSlices writes the expansion of the placeholders of any sqlc.slice arguments to match the number of elements
+// at runtime, returning the name of the variable containing the query text to prepare.
+func expandSlices(sb *IndentStringBuilder, config core.Config, q core.Query) string {
+	if !hasSliceArgs(q) {
+		return q.MethodName
+	}
+
+	sb.WriteIndentedString(2, "var query = "+q.MethodName)
+	for _, arg := range q.Args {
+		if !arg.IsSlice {
+			continue
+		}
+
+		// an empty list is replaced with NULL so that "IN (...)" matches nothing instead of being a syntax error
+		sb.WriteString("\n")
+		sb.WriteIndentedString(3, fmt.Sprintf(
+			".replace(\"%s\", %s.isEmpty() ? \"NULL\" : String.join(\",\", java.util.Collections.nCopies(%s.size(), \"?\")))",
+			sliceMarker(arg), argValue(config, q, arg), argValue(config, q, arg),
+		))
+	}
+	sb.WriteString(";\n")
+	return "query"
+}
+
+// writeSliceElementCheck writes the check rejecting null elements of the given sqlc.slice argument, which would
+// otherwise fail with an unhelpful NullPointerException when bound.
+func writeSliceElementCheck(sb *IndentStringBuilder, arg core.QueryArg, level int) {
+	sb.WriteIndentedString(level, fmt.Sprintf(
+		"java.util.Objects.requireNonNull(elem, \"%s must not contain null elements\");\n", arg.Name,
+	))
+}
+
+// bindArgs writes the argument bind statements for the given query at the given indent level. If the query contains
+// any sqlc.slice arguments, the parameter indexes are computed as each argument is bound.
+func bindArgs(sb *IndentStringBuilder, config core.Config, engine string, q core.Query, level int) {
+	if !hasSliceArgs(q) {
+		for _, binding := range argBindings(q) {
+			sb.WriteIndentedString(level, binding.Arg.BindStmtAt(engine, strconv.Itoa(binding.Index), argValue(config, q, binding.Arg))+"\n")
+		}
+		return
+	}
+
+	sb.WriteIndentedString(level, "var idx = 1;\n")
+	for _, arg := range q.Args {
+		if !arg.IsSlice {
+			sb.WriteIndentedString(level, arg.BindStmtAt(engine, "idx++", argValue(config, q, arg))+"\n")
+			continue
+		}
+
+		elem := arg
+		elem.JavaType.IsList = false
+		elem.JavaType.IsNullable = false
+
+		sb.WriteIndentedString(level, "for (var elem : "+argValue(config, q, arg)+") {\n")
+		writeSliceElementCheck(sb, arg, level+1)
+		sb.WriteIndentedString(level+1, elem.BindStmtAt(engine, "idx++", "elem")+"\n")
+		sb.WriteIndentedString(level, "}\n")
+	}
+}
+
+// openStatement writes the preparation of the statement for the given query, followed by the argument bind
+// statements.
+func openStatement(sb *IndentStringBuilder, config core.Config, engine string, q core.Query) {
+	queryText := expandSlices(sb, config, q)
+
+	if q.Command == core.ExecResult {
+		sb.WriteIndentedString(2, "try (var stmt = conn.prepareStatement("+queryText+", java.sql.Statement.RETURN_GENERATED_KEYS)) {\n")
+	} else {
+		sb.WriteIndentedString(2, "try (var stmt = conn.prepareStatement("+queryText+")) {\n")
 	}
+	bindArgs(sb, config, engine, q, 3)
+}
+
+// completeMethodBody writes the remainder of the method body following the argument bind statements. The statement
+// and any result set are managed using try-with-resources so that they are always closed once the method returns.
+func completeMethodBody(sb *IndentStringBuilder, config core.Config, engine string, q core.Query, embeddedModels core.EmbeddedModels, t *importTracker) {
+	sb.WriteString("\n")
 
 	switch q.Command {
 	case core.One:
-		sb.WriteIndentedString(2, "if (!results.next()) {\n")
-		sb.WriteIndentedString(3, "return Optional.empty();\n")
-		sb.WriteIndentedString(2, "}\n\n")
-		createResultRecord(sb, 2, q, embeddedModels)
-		sb.WriteIndentedString(2, "if (results.next()) {\n")
-		sb.WriteIndentedString(3, "throw new SQLException(\"expected one row in result set, but got many\");\n")
-		sb.WriteIndentedString(2, "}\n\n")
-		sb.WriteIndentedString(2, "return Optional.of(ret);\n")
+		sb.WriteIndentedString(3, "try (var results = stmt.executeQuery()) {\n")
+		sb.WriteIndentedString(4, "if (!results.next()) {\n")
+		sb.WriteIndentedString(5, "return "+t.Type("java.util.Optional")+".empty();\n")
+		sb.WriteIndentedString(4, "}\n\n")
+		createResultRecord(sb, jdbcColumns(engine), 4, q, embeddedModels, t)
+		sb.WriteIndentedString(4, "if (results.next()) {\n")
+		sb.WriteIndentedString(5, "throw new SQLException(\"expected one row in result set, but got many\");\n")
+		sb.WriteIndentedString(4, "}\n\n")
+		sb.WriteIndentedString(4, "return "+optionalOf(q, t)+"(ret);\n")
+		sb.WriteIndentedString(3, "}\n")
 	case core.Many:
-		jt := resultRecordName(q)
-		if len(q.Returns) == 1 {
-			_, jt, _ = core.ResolveImportAndType(q.Returns[0].JavaType.Type)
-			if q.Returns[0].EmbeddedModel != nil {
-				jt = *q.Returns[0].EmbeddedModel
-			}
-		}
-
-		sb.WriteIndentedString(2, "var retList = new ArrayList<"+jt+">();\n")
-		sb.WriteIndentedString(2, "while (results.next()) {\n")
-		createResultRecord(sb, 3, q, embeddedModels)
-		sb.WriteIndentedString(3, "retList.add(ret);\n")
-		sb.WriteIndentedString(2, "}\n\n")
-		sb.WriteIndentedString(2, "return retList;\n")
+		sb.WriteIndentedString(3, "try (var results = stmt.executeQuery()) {\n")
+		sb.WriteIndentedString(4, "var retList = new "+t.Type("java.util.ArrayList")+"<"+rowType(q, t)+">();\n")
+		sb.WriteIndentedString(4, "while (results.next()) {\n")
+		createResultRecord(sb, jdbcColumns(engine), 5, q, embeddedModels, t)
+		sb.WriteIndentedString(5, "retList.add(ret);\n")
+		sb.WriteIndentedString(4, "}\n\n")
+		sb.WriteIndentedString(4, "return retList;\n")
+		sb.WriteIndentedString(3, "}\n")
 	case core.Exec:
-		break
+		sb.WriteIndentedString(3, "stmt.execute();\n")
 	case core.ExecRows:
-		sb.WriteIndentedString(2, "return stmt.getUpdateCount();\n")
+		sb.WriteIndentedString(3, "stmt.execute();\n")
+		sb.WriteIndentedString(3, "return stmt.getUpdateCount();\n")
 	case core.ExecResult:
-		sb.WriteIndentedString(2, "var results = stmt.getGeneratedKeys();\n")
-		sb.WriteIndentedString(2, "if (!results.next()) {\n")
-		sb.WriteIndentedString(3, "throw new SQLException(\"no generated key returned\");\n")
-		sb.WriteIndentedString(2, "}\n\n")
-		sb.WriteIndentedString(2, "return results.getLong(1);\n")
+		sb.WriteIndentedString(3, "stmt.execute();\n")
+		sb.WriteIndentedString(3, "try (var results = stmt.getGeneratedKeys()) {\n")
+		sb.WriteIndentedString(4, "if (!results.next()) {\n")
+		sb.WriteIndentedString(5, "throw new SQLException(\"no generated key returned\");\n")
+		sb.WriteIndentedString(4, "}\n\n")
+		sb.WriteIndentedString(4, "return results.getLong(1);\n")
+		sb.WriteIndentedString(3, "}\n")
 	default:
-		sb.WriteIndentedString(2, "// TODO\n")
+		sb.WriteIndentedString(3, "// TODO\n")
 	}
+
+	sb.WriteIndentedString(2, "}\n")
 }
 
-func BuildQueriesFile(engine string, config core.Config, queryFilename string, queries []core.Query, embeddedModels core.EmbeddedModels, nullableHelpers core.NullableHelpers) (string, []byte, error) {
+// writeStreamMethodBody writes the body of the streaming variant of a :many query. The statement and result set are
+// left open until the returned stream is closed.
+func writeStreamMethodBody(sb *IndentStringBuilder, config core.Config, engine string, q core.Query, embeddedModels core.EmbeddedModels, t *importTracker) {
+	queryText := expandSlices(sb, config, q)
+
+	if len(q.Args) == 0 {
+		sb.WriteIndentedString(2, "return streamResults("+queryText+", stmt -> {}, results -> {\n")
+	} else {
+		sb.WriteIndentedString(2, "return streamResults("+queryText+", stmt -> {\n")
+		bindArgs(sb, config, engine, q, 3)
+		sb.WriteIndentedString(2, "}, results -> {\n")
+	}
+	createResultRecord(sb, jdbcColumns(engine), 3, q, embeddedModels, t)
+	sb.WriteIndentedString(3, "return ret;\n")
+	sb.WriteIndentedString(2, "});\n")
+}
+
+// completeBatchBody writes the body of the method generated for a batch query. Every element of the params list is
+// sent to the database using a single JDBC batch.
+func completeBatchBody(sb *IndentStringBuilder, config core.Config, engine string, q core.Query, embeddedModels core.EmbeddedModels, t *importTracker) {
+	if q.Command != core.BatchExec {
+		writeBatchRows(sb, config, engine, q, 2, embeddedModels, t)
+		return
+	}
+
+	sb.WriteIndentedString(2, "try (var stmt = conn.prepareStatement("+q.MethodName+")) {\n")
+	sb.WriteIndentedString(3, "for (var row : params) {\n")
+	bindArgs(sb, config, engine, q, 4)
+	sb.WriteIndentedString(4, "stmt.addBatch();\n")
+	sb.WriteIndentedString(3, "}\n\n")
+	sb.WriteIndentedString(3, "return stmt.executeBatch();\n")
+	sb.WriteIndentedString(2, "}\n")
+}
+
+// writeBatchRows writes the execution of a :batchone or :batchmany query at the given indent level, using the
+// connection named "conn". The rows are read from the generated keys of a single JDBC batch where the driver supports
+// it, otherwise each element of the batch is executed as a separate query.
+func writeBatchRows(sb *IndentStringBuilder, config core.Config, engine string, q core.Query, level int, embeddedModels core.EmbeddedModels, t *importTracker) {
+	if q.BatchReturning {
+		writeBatchReturning(sb, config, engine, q, level, embeddedModels, t)
+		return
+	}
+
+	jt := rowType(q, t)
+	elemType := t.Type("java.util.List") + "<" + jt + ">"
+	if q.Command == core.BatchOne {
+		elemType = t.Type("java.util.Optional") + "<" + jt + ">"
+	}
+
+	sb.WriteIndentedString(level, "try (var stmt = conn.prepareStatement("+q.MethodName+")) {\n")
+	sb.WriteIndentedString(level+1, "var retList = new "+t.Type("java.util.ArrayList")+"<"+elemType+">(params.size());\n")
+	sb.WriteIndentedString(level+1, "for (var row : params) {\n")
+	bindArgs(sb, config, engine, q, level+2)
+	sb.WriteString("\n")
+	sb.WriteIndentedString(level+2, "try (var results = stmt.executeQuery()) {\n")
+
+	if q.Command == core.BatchOne {
+		sb.WriteIndentedString(level+3, "if (!results.next()) {\n")
+		sb.WriteIndentedString(level+4, "retList.add("+t.Type("java.util.Optional")+".empty());\n")
+		sb.WriteIndentedString(level+4, "continue;\n")
+		sb.WriteIndentedString(level+3, "}\n\n")
+		createResultRecord(sb, jdbcColumns(engine), level+3, q, embeddedModels, t)
+		sb.WriteIndentedString(level+3, "if (results.next()) {\n")
+		sb.WriteIndentedString(level+4, "throw new SQLException(\"expected one row in result set, but got many\");\n")
+		sb.WriteIndentedString(level+3, "}\n\n")
+		sb.WriteIndentedString(level+3, "retList.add("+optionalOf(q, t)+"(ret));\n")
+	} else {
+		sb.WriteIndentedString(level+3, "var rows = new "+t.Type("java.util.ArrayList")+"<"+jt+">();\n")
+		sb.WriteIndentedString(level+3, "while (results.next()) {\n")
+		createResultRecord(sb, jdbcColumns(engine), level+4, q, embeddedModels, t)
+		sb.WriteIndentedString(level+4, "rows.add(ret);\n")
+		sb.WriteIndentedString(level+3, "}\n")
+		sb.WriteIndentedString(level+3, "retList.add(rows);\n")
+	}
+
+	sb.WriteIndentedString(level+2, "}\n")
+	sb.WriteIndentedString(level+1, "}\n\n")
+	sb.WriteIndentedString(level+1, "return retList;\n")
+	sb.WriteIndentedString(level, "}\n")
+}
+
+// writeBatchReturning writes the execution of a :batchone or :batchmany query as a single JDBC batch. JDBC batches
+// cannot return result sets, so the rows returned by the RETURNING clause are read through the generated keys, and
+// split between the elements of the batch using their update counts. Only pgjdbc supports this.
+func writeBatchReturning(sb *IndentStringBuilder, config core.Config, engine string, q core.Query, level int, embeddedModels core.EmbeddedModels, t *importTracker) {
+	jt := rowType(q, t)
+	elemType := t.Type("java.util.List") + "<" + jt + ">"
+	if q.Command == core.BatchOne {
+		elemType = t.Type("java.util.Optional") + "<" + jt + ">"
+	}
+
+	sb.WriteIndentedString(level, "try (var stmt = conn.prepareStatement("+q.MethodName+", java.sql.Statement.RETURN_GENERATED_KEYS)) {\n")
+	sb.WriteIndentedString(level+1, "for (var row : params) {\n")
+	bindArgs(sb, config, engine, q, level+2)
+	sb.WriteIndentedString(level+2, "stmt.addBatch();\n")
+	sb.WriteIndentedString(level+1, "}\n\n")
+	sb.WriteIndentedString(level+1, "var counts = stmt.executeBatch();\n")
+	sb.WriteIndentedString(level+1, "var retList = new "+t.Type("java.util.ArrayList")+"<"+elemType+">(counts.length);\n")
+	sb.WriteIndentedString(level+1, "try (var results = stmt.getGeneratedKeys()) {\n")
+	sb.WriteIndentedString(level+2, "for (var reported : counts) {\n")
+	// rewritten batches, e.g. using reWriteBatchedInserts, do not report per element counts - each element of a
+	// rewritten multi-row insert returns exactly one row
+	sb.WriteIndentedString(level+3, "var count = reported == java.sql.Statement.SUCCESS_NO_INFO ? 1 : reported;\n")
+
+	if q.Command == core.BatchOne {
+		sb.WriteIndentedString(level+3, "if (count == 0) {\n")
+		sb.WriteIndentedString(level+4, "retList.add("+t.Type("java.util.Optional")+".empty());\n")
+		sb.WriteIndentedString(level+4, "continue;\n")
+		sb.WriteIndentedString(level+3, "}\n")
+		sb.WriteIndentedString(level+3, "if (count > 1) {\n")
+		sb.WriteIndentedString(level+4, "throw new SQLException(\"expected one row in result set, but got many\");\n")
+		sb.WriteIndentedString(level+3, "}\n")
+		sb.WriteIndentedString(level+3, "if (!results.next()) {\n")
+		sb.WriteIndentedString(level+4, "throw new SQLException(\"expected a row to be returned for the batch element\");\n")
+		sb.WriteIndentedString(level+3, "}\n\n")
+		createResultRecord(sb, jdbcColumns(engine), level+3, q, embeddedModels, t)
+		sb.WriteIndentedString(level+3, "retList.add("+optionalOf(q, t)+"(ret));\n")
+	} else {
+		sb.WriteIndentedString(level+3, "var rows = new "+t.Type("java.util.ArrayList")+"<"+jt+">(count);\n")
+		sb.WriteIndentedString(level+3, "for (var i = 0; i < count; i++) {\n")
+		sb.WriteIndentedString(level+4, "if (!results.next()) {\n")
+		sb.WriteIndentedString(level+5, "throw new SQLException(\"expected a row to be returned for the batch element\");\n")
+		sb.WriteIndentedString(level+4, "}\n\n")
+		createResultRecord(sb, jdbcColumns(engine), level+4, q, embeddedModels, t)
+		sb.WriteIndentedString(level+4, "rows.add(ret);\n")
+		sb.WriteIndentedString(level+3, "}\n")
+		sb.WriteIndentedString(level+3, "retList.add(rows);\n")
+	}
+
+	sb.WriteIndentedString(level+2, "}\n")
+	sb.WriteIndentedString(level+1, "}\n\n")
+	sb.WriteIndentedString(level+1, "return retList;\n")
+	sb.WriteIndentedString(level, "}\n")
+}
+
+func completeCopyFromBody(sb *IndentStringBuilder, config core.Config, engine string, q core.Query) {
+	if engine == "postgresql" {
+		// stream the rows using COPY FROM STDIN when the underlying connection is provided by pgjdbc
+		sb.WriteIndentedString(2, "var pgConn = unwrapPgConnection(conn);\n")
+		sb.WriteIndentedString(2, "if (pgConn != null) {\n")
+		sb.WriteIndentedString(3, "return copyIn(pgConn, "+copyStatementName(q)+", params, (line, row) -> {\n")
+		for i, arg := range q.Args {
+			if i > 0 {
+				sb.WriteIndentedString(4, "line.append(',');\n")
+			}
+			sb.WriteIndentedString(4, "appendCopyField(line, "+arg.CopyValue(argValue(config, q, arg))+");\n")
+		}
+		sb.WriteIndentedString(3, "});\n")
+		sb.WriteIndentedString(2, "}\n\n")
+	}
+
+	writeBatchInsert(sb, config, engine, q, 2)
+}
+
+// writeBatchInsert writes the insertion of the rows of a :copyfrom query using a JDBC batch at the given indent level,
+// using the connection named "conn". The batch is executed every COPY_BATCH_SIZE rows, so that the driver does not
+// hold every row in memory at once.
+func writeBatchInsert(sb *IndentStringBuilder, config core.Config, engine string, q core.Query, level int) {
+	sb.WriteIndentedString(level, "try (var stmt = conn.prepareStatement("+q.MethodName+")) {\n")
+	sb.WriteIndentedString(level+1, "var inserted = 0L;\n")
+	sb.WriteIndentedString(level+1, "var batched = 0;\n")
+	sb.WriteIndentedString(level+1, "for (var row : params) {\n")
+	bindArgs(sb, config, engine, q, level+2)
+	sb.WriteIndentedString(level+2, "stmt.addBatch();\n\n")
+	sb.WriteIndentedString(level+2, "if (++batched == COPY_BATCH_SIZE) {\n")
+	sb.WriteIndentedString(level+3, "inserted += countUpdated(stmt.executeBatch());\n")
+	sb.WriteIndentedString(level+3, "batched = 0;\n")
+	sb.WriteIndentedString(level+2, "}\n")
+	sb.WriteIndentedString(level+1, "}\n\n")
+	sb.WriteIndentedString(level+1, "if (batched > 0) {\n")
+	sb.WriteIndentedString(level+2, "inserted += countUpdated(stmt.executeBatch());\n")
+	sb.WriteIndentedString(level+1, "}\n\n")
+	sb.WriteIndentedString(level+1, "return inserted;\n")
+	sb.WriteIndentedString(level, "}\n")
+}
+
+// writeConstructors writes the fields holding the database connection, the constructors initialising them, and their
+// getters if they are exposed.
+func (b *IndentStringBuilder) writeConstructors(className string, config core.Config) {
+	if !config.EmitDataSourceConstructor {
+		b.WriteIndentedString(1, "private final java.sql.Connection conn;\n\n")
+		b.WriteIndentedString(1, "public "+className+"(java.sql.Connection conn) {\n")
+		b.WriteIndentedString(2, "this.conn = conn;\n")
+		b.WriteIndentedString(1, "}\n")
+
+		if config.ExposeConnection {
+			b.WriteString("\n")
+			b.WriteIndentedString(1, "public java.sql.Connection getConn() {return this.conn;}\n")
+		}
+		return
+	}
+
+	// exactly one of the connection or data source is set, depending on the constructor used
+	b.WriteIndentedString(1, "private final java.sql.Connection conn;\n")
+	b.WriteIndentedString(1, "private final javax.sql.DataSource dataSource;\n\n")
+	b.WriteIndentedString(1, "public "+className+"(java.sql.Connection conn) {\n")
+	b.WriteIndentedString(2, "this.conn = conn;\n")
+	b.WriteIndentedString(2, "this.dataSource = null;\n")
+	b.WriteIndentedString(1, "}\n\n")
+	b.WriteIndentedString(1, "public "+className+"(javax.sql.DataSource dataSource) {\n")
+	b.WriteIndentedString(2, "this.conn = null;\n")
+	b.WriteIndentedString(2, "this.dataSource = dataSource;\n")
+	b.WriteIndentedString(1, "}\n")
+
+	if config.ExposeConnection {
+		b.WriteString("\n")
+		b.WriteIndentedString(1, "public java.sql.Connection getConn() {return this.conn;}\n\n")
+		b.WriteIndentedString(1, "public javax.sql.DataSource getDataSource() {return this.dataSource;}\n")
+	}
+}
+
+// writeConnectionHelpers writes the methods used to borrow a connection for the duration of a single method call.
+// Connections are only borrowed from the data source if the queries class was not constructed using a connection.
+func (b *IndentStringBuilder) writeConnectionHelpers(nonNullAnnotation string) {
+	b.WriteString("\n")
+	b.WriteIndentedString(1, "private "+core.Annotate("java.sql.Connection", nonNullAnnotation)+" acquireConnection() throws SQLException {\n")
+	b.WriteIndentedString(2, "return conn != null ? conn : dataSource.getConnection();\n")
+	b.WriteIndentedString(1, "}\n\n")
+	b.WriteIndentedString(1, "private void releaseConnection("+core.Annotate("java.sql.Connection", nonNullAnnotation)+" acquired) throws SQLException {\n")
+	b.WriteIndentedString(2, "if (acquired != conn) acquired.close();\n")
+	b.WriteIndentedString(1, "}\n")
+}
+
+// writeMethodBody writes the given method body. If the queries class may be constructed using a data source, the body
+// is wrapped so that it uses a borrowed connection which is released once the method returns.
+func (b *IndentStringBuilder) writeMethodBody(config core.Config, methodBody string) {
+	if !config.EmitDataSourceConstructor {
+		b.WriteString(methodBody)
+		return
+	}
+
+	indent := strings.Repeat(config.IndentChar, config.CharsPerIndentLevel)
+	b.WriteIndentedString(2, "var conn = acquireConnection();\n")
+	b.WriteIndentedString(2, "try {\n")
+	for _, line := range strings.SplitAfter(methodBody, "\n") {
+		if strings.TrimSpace(line) != "" {
+			b.WriteString(indent)
+		}
+		b.WriteString(line)
+	}
+	b.WriteIndentedString(2, "} finally {\n")
+	b.WriteIndentedString(3, "releaseConnection(conn);\n")
+	b.WriteIndentedString(2, "}\n")
+}
+
+// writeTransactionHelpers writes the methods used to run several queries within a single transaction. The callback is
+// given a queries instance bound to the transaction's connection. If a transaction is already in progress, a savepoint
+// is used instead so that only the changes made by the callback are rolled back if it fails.
+func (b *IndentStringBuilder) writeTransactionHelpers(className string, config core.Config, nonNullAnnotation, nullableAnnotation string) {
+	b.WriteString("\n")
+	b.WriteIndentedString(1, "@FunctionalInterface\n")
+	b.WriteIndentedString(1, "public interface TransactionFunction<T> {\n")
+	b.WriteIndentedString(2, "T apply("+core.Annotate(className, nonNullAnnotation)+" queries) throws SQLException;\n")
+	b.WriteIndentedString(1, "}\n\n")
+	b.WriteIndentedString(1, "@FunctionalInterface\n")
+	b.WriteIndentedString(1, "public interface TransactionBody {\n")
+	b.WriteIndentedString(2, "void accept("+core.Annotate(className, nonNullAnnotation)+" queries) throws SQLException;\n")
+	b.WriteIndentedString(1, "}\n\n")
+
+	fn := core.Annotate("TransactionFunction<T>", nonNullAnnotation) + " fn"
+	body := core.Annotate("TransactionBody", nonNullAnnotation) + " body"
+
+	b.WriteIndentedString(1, "public <T> T withTransaction("+fn+") throws SQLException {\n")
+	b.WriteIndentedString(2, "return runTransaction(null, false, fn);\n")
+	b.WriteIndentedString(1, "}\n\n")
+	b.WriteIndentedString(1, "public <T> T withTransaction(int isolationLevel, boolean readOnly, "+fn+") throws SQLException {\n")
+	b.WriteIndentedString(2, "return runTransaction(isolationLevel, readOnly, fn);\n")
+	b.WriteIndentedString(1, "}\n\n")
+	b.WriteIndentedString(1, "public void inTransaction("+body+") throws SQLException {\n")
+	b.WriteIndentedString(2, "runTransaction(null, false, queries -> {\n")
+	b.WriteIndentedString(3, "body.accept(queries);\n")
+	b.WriteIndentedString(3, "return null;\n")
+	b.WriteIndentedString(2, "});\n")
+	b.WriteIndentedString(1, "}\n\n")
+	b.WriteIndentedString(1, "public void inTransaction(int isolationLevel, boolean readOnly, "+body+") throws SQLException {\n")
+	b.WriteIndentedString(2, "runTransaction(isolationLevel, readOnly, queries -> {\n")
+	b.WriteIndentedString(3, "body.accept(queries);\n")
+	b.WriteIndentedString(3, "return null;\n")
+	b.WriteIndentedString(2, "});\n")
+	b.WriteIndentedString(1, "}\n\n")
+
+	b.WriteIndentedString(1, "private <T> T runTransaction("+core.Annotate("Integer", nullableAnnotation)+" isolationLevel, boolean readOnly, "+fn+") throws SQLException {\n")
+	methodBody := NewIndentStringBuilder(b.indentChar, b.charsPerIndentLevel)
+	// the isolation level and read-only flag cannot be changed part way through a transaction
+	methodBody.WriteIndentedString(2, "if (!conn.getAutoCommit()) {\n")
+	methodBody.WriteIndentedString(3, "var savepoint = conn.setSavepoint();\n")
+	methodBody.WriteIndentedString(3, "try {\n")
+	methodBody.WriteIndentedString(4, "var ret = fn.apply(new "+className+"(conn));\n")
+	methodBody.WriteIndentedString(4, "conn.releaseSavepoint(savepoint);\n")
+	methodBody.WriteIndentedString(4, "return ret;\n")
+	methodBody.WriteIndentedString(3, "} catch (Throwable e) {\n")
+	methodBody.WriteIndentedString(4, "try {\n")
+	methodBody.WriteIndentedString(5, "conn.rollback(savepoint);\n")
+	methodBody.WriteIndentedString(4, "} catch (SQLException suppressed) {\n")
+	methodBody.WriteIndentedString(5, "e.addSuppressed(suppressed);\n")
+	methodBody.WriteIndentedString(4, "}\n")
+	methodBody.WriteIndentedString(4, "throw e;\n")
+	methodBody.WriteIndentedString(3, "}\n")
+	methodBody.WriteIndentedString(2, "}\n\n")
+	methodBody.WriteIndentedString(2, "var previousIsolationLevel = conn.getTransactionIsolation();\n")
+	methodBody.WriteIndentedString(2, "var previousReadOnly = conn.isReadOnly();\n")
+	methodBody.WriteIndentedString(2, "if (isolationLevel != null) conn.setTransactionIsolation(isolationLevel);\n")
+	methodBody.WriteIndentedString(2, "if (readOnly) conn.setReadOnly(true);\n")
+	methodBody.WriteIndentedString(2, "conn.setAutoCommit(false);\n")
+	methodBody.WriteIndentedString(2, "try {\n")
+	methodBody.WriteIndentedString(3, "var ret = fn.apply(new "+className+"(conn));\n")
+	methodBody.WriteIndentedString(3, "conn.commit();\n")
+	methodBody.WriteIndentedString(3, "return ret;\n")
+	methodBody.WriteIndentedString(2, "} catch (Throwable e) {\n")
+	methodBody.WriteIndentedString(3, "try {\n")
+	methodBody.WriteIndentedString(4, "conn.rollback();\n")
+	methodBody.WriteIndentedString(3, "} catch (SQLException suppressed) {\n")
+	methodBody.WriteIndentedString(4, "e.addSuppressed(suppressed);\n")
+	methodBody.WriteIndentedString(3, "}\n")
+	methodBody.WriteIndentedString(3, "throw e;\n")
+	methodBody.WriteIndentedString(2, "} finally {\n")
+	methodBody.WriteIndentedString(3, "conn.setAutoCommit(true);\n")
+	methodBody.WriteIndentedString(3, "if (readOnly) conn.setReadOnly(previousReadOnly);\n")
+	methodBody.WriteIndentedString(3, "if (isolationLevel != null) conn.setTransactionIsolation(previousIsolationLevel);\n")
+	methodBody.WriteIndentedString(2, "}\n")
+	b.writeMethodBody(config, methodBody.String())
+	b.WriteIndentedString(1, "}\n")
+}
+
+func queriesClassBaseName(queryFilename string) string {
 	className := strcase.ToCamel(strings.TrimSuffix(queryFilename, ".sql"))
 	className = strings.TrimSuffix(className, "Query")
 	className = strings.TrimSuffix(className, "Queries")
-	className += "Queries"
+	return className
+}
+
+// QueriesClassName returns the name of the queries class generated for the given query file.
+func QueriesClassName(queryFilename string) string {
+	return queriesClassBaseName(queryFilename) + "Queries"
+}
+
+// QuerierInterfaceName returns the name of the interface generated for the given query file.
+func QuerierInterfaceName(queryFilename string) string {
+	return queriesClassBaseName(queryFilename) + "Querier"
+}
+
+// queriesJdkTypes are the types referenced by simple name within the generated queries classes. They are reserved
+// before any other type is referenced, so that types with the same simple name are fully qualified instead.
+var queriesJdkTypes = []string{
+	"java.sql.ResultSet", "java.sql.SQLException", "java.util.ArrayList", "java.util.Arrays", "java.util.List",
+	"java.util.Optional", "java.util.stream.Stream", "javax.annotation.processing.Generated",
+}
+
+// queriesPackageTypes returns the simple names of the types declared by the queries class and interface generated
+// for the given query file.
+func queriesPackageTypes(queryFilename string, queries []core.Query) []string {
+	types := []string{
+		QueriesClassName(queryFilename), QuerierInterfaceName(queryFilename),
+		"StatementBinder", "RowMapper", "TransactionFunction", "TransactionBody", "CopyRowWriter", "Builder",
+	}
+	for _, q := range queries {
+		types = append(types, resultRecordName(q), paramsRecordName(q))
+	}
+	return types
+}
+
+// rowType resolves the type of each row returned by the given query.
+func rowType(q core.Query, t *importTracker) string {
+	if len(q.Returns) != 1 {
+		return resultRecordName(q)
+	}
+
+	// the query only outputs a single value, we don't need to wrap it in an xxRow record class
+	ret := q.Returns[0]
+	jt := t.Type(ret.JavaType.Type)
+	if ret.JavaType.IsList {
+		jt = t.Type("java.util.List") + "<" + jt + ">"
+	}
+	return jt
+}
 
-	imports := make([]string, 0)
-	imports = append(imports, "java.sql.SQLException", "java.sql.ResultSet", "java.util.Arrays", "javax.annotation.processing.Generated")
+// methodReturnType resolves the return type of the method generated for the given query.
+func methodReturnType(q core.Query, t *importTracker) string {
+	var returnType string
+	if len(q.Returns) > 0 {
+		returnType = rowType(q, t)
+	}
+
+	switch q.Command {
+	case core.One:
+		returnType = t.Type("java.util.Optional") + "<" + returnType + ">"
+	case core.Many:
+		returnType = t.Type("java.util.List") + "<" + returnType + ">"
+	case core.Exec:
+		returnType = "void"
+	case core.ExecRows:
+		returnType = "int"
+	case core.ExecResult, core.CopyFrom:
+		returnType = "long"
+	case core.BatchExec:
+		returnType = "int[]"
+	case core.BatchOne:
+		returnType = t.Type("java.util.List") + "<" + t.Type("java.util.Optional") + "<" + returnType + ">>"
+	case core.BatchMany:
+		returnType = t.Type("java.util.List") + "<" + t.Type("java.util.List") + "<" + returnType + ">>"
+	}
+
+	return returnType
+}
+
+// boxedReturnType resolves the return type of the method generated for the given query, boxing any primitive type so
+// that it can be used as a type argument.
+func boxedReturnType(q core.Query, t *importTracker) string {
+	returnType := methodReturnType(q, t)
+	switch returnType {
+	case "void":
+		return "Void"
+	case "int":
+		return "Integer"
+	case "long":
+		return "Long"
+	default:
+		return returnType
+	}
+}
+
+// streamMethodName returns the name of the streaming variant of the method generated for the given :many query.
+func streamMethodName(q core.Query) string {
+	return q.MethodName + "Stream"
+}
+
+// streamReturnType resolves the return type of the streaming variant of the method generated for the given :many query.
+func streamReturnType(q core.Query, t *importTracker) string {
+	return t.Type("java.util.stream.Stream") + "<" + rowType(q, t) + ">"
+}
+
+// hasStreamQueries returns whether a streaming method will be generated for any of the given queries.
+func hasStreamQueries(queries []core.Query) bool {
+	return slices.ContainsFunc(queries, func(q core.Query) bool { return q.Command == core.Many && q.Stream })
+}
+
+// hasCopyFromQueries returns whether any of the given queries is a :copyfrom query.
+func hasCopyFromQueries(queries []core.Query) bool {
+	return slices.ContainsFunc(queries, func(q core.Query) bool { return q.Command == core.CopyFrom })
+}
+
+// writeMethodSignature writes the signature of the named method generated for the given query, up to and including
+// the throws clause. Any extra parameters, e.g. "Executor executor", are declared after the query's arguments.
+func (b *IndentStringBuilder) writeMethodSignature(modifiers, returnType, name string, q core.Query, throws string, t *importTracker, nonNullAnnotation, nullableAnnotation string, extraParams ...string) {
+	b.WriteIndentedString(1, fmt.Sprintf("%s%s %s(", modifiers, returnType, name))
+	if q.Command.TakesParamsList() {
+		// batches return a result for each element of the params, so must be given an ordered collection
+		paramsType := "Iterable"
+		if q.Command.IsBatch() {
+			paramsType = t.Type("java.util.List")
+		}
+
+		b.WriteString("\n")
+		b.WriteIndentedString(2, strings.TrimSpace(nonNullAnnotation+" "+paramsType+"<"+paramsRecordName(q)+">")+" params")
+		b.writeExtraParams(extraParams)
+		b.WriteIndentedString(1, ")"+throws)
+		return
+	}
+
+	if q.UseParamsRecord {
+		b.WriteString("\n")
+		b.WriteIndentedString(2, strings.TrimSpace(nonNullAnnotation+" "+paramsRecordName(q))+" params")
+		b.writeExtraParams(extraParams)
+		b.WriteIndentedString(1, ")"+throws)
+		return
+	}
+
+	if len(q.Args) == 0 && len(extraParams) == 0 {
+		b.WriteString(")" + throws)
+		return
+	}
+
+	b.WriteString("\n")
+	for i, arg := range q.Args {
+		b.writeParameter(arg.JavaType, arg.Name, t, nonNullAnnotation, nullableAnnotation)
+
+		if i != len(q.Args)-1 {
+			b.WriteString(",\n")
+		}
+	}
+	if len(q.Args) == 0 {
+		b.WriteIndentedString(2, extraParams[0])
+		extraParams = extraParams[1:]
+	}
+	b.writeExtraParams(extraParams)
+	b.WriteIndentedString(1, ")"+throws)
+}
+
+// writeExtraParams writes the given parameters following the final parameter of a method signature, terminating the
+// parameter list.
+func (b *IndentStringBuilder) writeExtraParams(params []string) {
+	for _, param := range params {
+		b.WriteString(",\n")
+		b.WriteIndentedString(2, param)
+	}
+	b.WriteString("\n")
+}
 
-	var nonNullAnnotation string
-	if config.NonNullAnnotation != "" {
-		imports = append(imports, config.NonNullAnnotation)
-		nonNullAnnotation = "@" + config.NonNullAnnotation[strings.LastIndex(config.NonNullAnnotation, ".")+1:]
+// throwsClause returns the throws clause of the methods generated for each query. Methods using the spring-jdbc backend
+// throw unchecked DataAccessExceptions instead.
+func throwsClause(config core.Config) string {
+	switch config.Backend {
+	case core.BackendSpringJdbc, core.BackendR2dbc, core.BackendVertx:
+		return ""
+	default:
+		return " throws SQLException"
 	}
-	var nullableAnnotation string
-	if config.NullableAnnotation != "" {
-		imports = append(imports, config.NullableAnnotation)
-		nullableAnnotation = "@" + config.NullableAnnotation[strings.LastIndex(config.NullableAnnotation, ".")+1:]
+}
+
+func writeImports(header *IndentStringBuilder, t *importTracker) {
+	for _, imp := range t.Imports() {
+		header.WriteString("import " + imp + ";\n")
 	}
+}
 
+// newQueriesClass writes the file header and the opening of the class declaration shared by the queries classes of
+// every backend. It returns the builder for the header, which the imports are written to once the class is complete,
+// the builder for the class body, and the modifiers of each method, which implement the querier interface if it is
+// generated.
+func newQueriesClass(config core.Config, queryFilename string, t *importTracker) (*IndentStringBuilder, *IndentStringBuilder, string) {
 	header := NewIndentStringBuilder(config.IndentChar, config.CharsPerIndentLevel)
 	header.writeSqlcHeader()
 	header.WriteString("\n")
 	header.WriteString("package " + config.Package + ";\n")
 	header.WriteString("\n")
 
+	classDeclaration := "public class " + QueriesClassName(queryFilename)
+	methodModifiers := "public "
+	if config.EmitInterface {
+		classDeclaration += " implements " + QuerierInterfaceName(queryFilename)
+		methodModifiers = "@Override\n" + strings.Repeat(config.IndentChar, config.CharsPerIndentLevel) + "public "
+	}
+
 	body := NewIndentStringBuilder(config.IndentChar, config.CharsPerIndentLevel)
 	body.WriteString("\n")
-	// Add the class declaration and constructor
-	body.WriteString("@Generated(\"io.github.tandemdude.sqlc-gen-java\")\n")
-	body.WriteString("public class " + className + " {\n")
-	body.WriteIndentedString(1, "private final java.sql.Connection conn;\n\n")
-	body.WriteIndentedString(1, "public "+className+"(java.sql.Connection conn) {\n")
-	body.WriteIndentedString(2, "this.conn = conn;\n")
-	body.WriteIndentedString(1, "}\n")
+	body.WriteString("@" + t.Type("javax.annotation.processing.Generated") + "(\"io.github.tandemdude.sqlc-gen-java\")\n")
+	body.WriteString(classDeclaration + " {\n")
+	return header, body, methodModifiers
+}
 
-	if config.ExposeConnection {
-		body.WriteString("\n")
-		body.WriteIndentedString(1, "public java.sql.Connection getConn() {return this.conn;}\n")
-	}
+// finishQueriesClass closes the class declaration started by newQueriesClass, returning the contents of the file.
+func finishQueriesClass(className string, header, body *IndentStringBuilder, t *importTracker) (string, []byte, error) {
+	body.WriteString("}\n")
+	writeImports(header, t)
+	return className + ".java", []byte(header.String() + body.String()), nil
+}
 
-	// boilerplate methods to allow for getting null primitive values
-	body.WriteString("\n")
+func BuildQuerierFile(config core.Config, queryFilename string, queries []core.Query) (string, []byte, error) {
+	interfaceName := QuerierInterfaceName(queryFilename)
 
-	imp := body.writeNullableHelpers(nullableHelpers, nonNullAnnotation, nullableAnnotation)
-	imports = append(imports, imp...)
+	t := newImportTracker(config.Package, queriesPackageTypes(queryFilename, queries)...)
+	t.reserve(queriesJdkTypes...)
+	nonNullAnnotation := t.Annotation(config.NonNullAnnotation)
+	nullableAnnotation := t.Annotation(config.NullableAnnotation)
 
-	for _, q := range queries {
-		body.WriteString("\n")
+	header := NewIndentStringBuilder(config.IndentChar, config.CharsPerIndentLevel)
+	header.writeSqlcHeader()
+	header.WriteString("\n")
+	header.WriteString("package " + config.Package + ";\n")
+	header.WriteString("\n")
 
-		// write the static attribute containing the query string
-		body.WriteIndentedString(1, "private static final String "+q.MethodName+" = \"\"\"\n")
-		body.WriteIndentedString(2, "-- name: "+q.RawQueryName+" "+q.RawCommand+"\n")
-		// for each line in the query, ensure it is indented correctly
-		for _, part := range strings.Split(q.Text, "\n") {
-			if part == "" {
-				continue
-			}
+	body := NewIndentStringBuilder(config.IndentChar, config.CharsPerIndentLevel)
+	body.WriteString("\n")
+	body.WriteString("@" + t.Type("javax.annotation.processing.Generated") + "(\"io.github.tandemdude.sqlc-gen-java\")\n")
+	body.WriteString("public interface " + interfaceName + " {\n")
+	if throwsClause(config) != "" {
+		t.Type("java.sql.SQLException")
+	}
+	for i, q := range queries {
+		if i > 0 {
+			body.WriteString("\n")
+		}
 
-			body.WriteIndentedString(2, part+"\n")
+		// the records are nested in the interface so that its callers do not depend on the queries class
+		records := NewIndentStringBuilder(config.IndentChar, config.CharsPerIndentLevel)
+		records.writeQueryRecords(config, q, t, nonNullAnnotation, nullableAnnotation)
+		if records.Len() > 0 {
+			body.WriteString(strings.TrimPrefix(records.String(), "\n") + "\n")
 		}
-		body.WriteIndentedString(2, "\"\"\";\n")
 
-		// write the output record class
-		var returnType string
-		if len(q.Returns) > 1 {
-			returnType = resultRecordName(q)
+		returnType := methodReturnType(q, t)
+		switch config.Backend {
+		case core.BackendR2dbc:
+			returnType = r2dbcReturnType(q, t)
+		case core.BackendVertx:
+			returnType = vertxReturnType(q, t)
+		}
+		body.writeMethodSignature("", returnType, q.MethodName, q, throwsClause(config), t, nonNullAnnotation, nullableAnnotation)
+		body.WriteString(";\n")
 
+		if config.EmitAsync {
 			body.WriteString("\n")
-			body.WriteIndentedString(1, "public record "+returnType+"(\n")
-			for i, ret := range q.Returns {
-				imps, err := body.writeParameter(ret.JavaType, ret.Name, nonNullAnnotation, nullableAnnotation)
-				if err != nil {
-					return "", nil, err
-				}
-				if imps != nil {
-					imports = append(imports, imps...)
-				}
-
-				if i != len(q.Returns)-1 {
-					body.WriteString(",\n")
-				}
-			}
+			body.writeMethodSignature("", asyncReturnType(q, t), asyncMethodName(q), q, "", t, nonNullAnnotation, nullableAnnotation, asyncExecutorParam(t, nonNullAnnotation))
+			body.WriteString(";\n")
+		}
+
+		// the reactive backends never generate a streaming variant
+		if q.Command == core.Many && q.Stream && !config.IsReactiveBackend() {
 			body.WriteString("\n")
-			body.WriteIndentedString(1, ") {}\n")
-		} else if len(q.Returns) == 1 {
-			// the query only outputs a single value, we don't need to wrap it in an xxRow record class
-			ret := q.Returns[0]
-
-			imp, jt, err := core.ResolveImportAndType(ret.JavaType.Type)
-			if err != nil {
-				return "", nil, err
-			}
-			imports = append(imports, imp)
+			body.writeMethodSignature("", streamReturnType(q, t), streamMethodName(q), q, throwsClause(config), t, nonNullAnnotation, nullableAnnotation)
+			body.WriteString(";\n")
+		}
+	}
+	body.WriteString("}\n")
 
-			if ret.JavaType.IsList {
-				imports = append(imports, "java.util.List")
-				jt = "List<" + jt + ">"
-			}
+	writeImports(header, t)
+
+	return interfaceName + ".java", []byte(header.String() + body.String()), nil
+}
 
-			returnType = jt
+func BuildQueriesFile(engine string, config core.Config, queryFilename string, queries []core.Query, embeddedModels core.EmbeddedModels, nullableHelpers core.NullableHelpers) (string, []byte, error) {
+	className := QueriesClassName(queryFilename)
+
+	t := newImportTracker(config.Package, queriesPackageTypes(queryFilename, queries)...)
+	t.reserve(queriesJdkTypes...)
+	nonNullAnnotation := t.Annotation(config.NonNullAnnotation)
+	nullableAnnotation := t.Annotation(config.NullableAnnotation)
+	for _, typ := range []string{"java.sql.SQLException", "java.sql.ResultSet", "java.util.Arrays"} {
+		t.Type(typ)
+	}
+
+	header, body, methodModifiers := newQueriesClass(config, queryFilename, t)
+
+	// Add the constructor
+	body.writeConstructors(className, config)
+
+	// boilerplate methods to allow for getting null primitive values
+	body.WriteString("\n")
+
+	body.writeNullableHelpers(nullableHelpers, t, nonNullAnnotation, nullableAnnotation)
+
+	if config.EmitDataSourceConstructor {
+		body.writeConnectionHelpers(nonNullAnnotation)
+	}
+
+	body.writeTransactionHelpers(className, config, nonNullAnnotation, nullableAnnotation)
+
+	if hasStreamQueries(queries) {
+		body.writeStreamHelpers(engine, config, t, nonNullAnnotation, nullableAnnotation)
+	}
+
+	if hasCopyFromQueries(queries) {
+		if engine == "postgresql" {
+			body.writeCopyInHelpers(t, nonNullAnnotation, nullableAnnotation)
 		}
+		body.writeBatchInsertHelpers()
+	}
+
+	for _, q := range queries {
+		body.WriteString("\n")
+
+		body.writeQueryText(config, q)
 
-		// figure out what the return type of the method should be
-		switch q.Command {
-		case core.One:
-			imports = append(imports, "java.util.Optional")
-			returnType = "Optional<" + returnType + ">"
-		case core.Many:
-			imports = append(imports, "java.util.List", "java.util.ArrayList")
-			returnType = "List<" + returnType + ">"
-		case core.Exec:
-			returnType = "void"
-		case core.ExecRows:
-			returnType = "int"
-		case core.ExecResult:
-			returnType = "long"
-		case core.CopyFrom:
-			return "", []byte{}, errors.New("copyFrom is not currently supported")
+		if q.Command == core.CopyFrom && engine == "postgresql" {
+			body.WriteString("\n")
+			body.writeStringConstant(config, copyStatementName(q), []string{copyStatement(q)})
 		}
 
-		methodBody := NewIndentStringBuilder(config.IndentChar, config.CharsPerIndentLevel)
-		if q.Command == core.ExecResult {
-			methodBody.WriteIndentedString(2, "var stmt = conn.prepareStatement("+q.MethodName+", java.sql.Statement.RETURN_GENERATED_KEYS);\n")
-		} else {
-			methodBody.WriteIndentedString(2, "var stmt = conn.prepareStatement("+q.MethodName+");\n")
+		// the records are nested in the querier interface if it is generated, and inherited by the class
+		if !config.EmitInterface {
+			body.writeQueryRecords(config, q, t, nonNullAnnotation, nullableAnnotation)
 		}
 
 		// write the method signature
 		body.WriteString("\n")
-		body.WriteIndentedString(1, fmt.Sprintf("public %s %s(", returnType, q.MethodName))
-		if len(q.Args) > 0 {
-			body.WriteString("\n")
-
-			for i, arg := range q.Args {
-				imps, err := body.writeParameter(arg.JavaType, arg.Name, nonNullAnnotation, nullableAnnotation)
-				if err != nil {
-					return "", nil, err
-				}
-				if imps != nil {
-					imports = append(imports, imps...)
-				}
+		body.writeMethodSignature(methodModifiers, methodReturnType(q, t), q.MethodName, q, " throws SQLException", t, nonNullAnnotation, nullableAnnotation)
+		body.WriteString(" {\n")
 
-				if i != len(q.Args)-1 {
-					body.WriteString(",\n")
-				}
+		methodBody := NewIndentStringBuilder(config.IndentChar, config.CharsPerIndentLevel)
+		switch {
+		case q.Command == core.CopyFrom:
+			completeCopyFromBody(methodBody, config, engine, q)
+		case q.Command.IsBatch():
+			completeBatchBody(methodBody, config, engine, q, embeddedModels, t)
+		default:
+			openStatement(methodBody, config, engine, q)
+			completeMethodBody(methodBody, config, engine, q, embeddedModels, t)
+		}
+		body.writeMethodBody(config, methodBody.String())
+		body.WriteIndentedString(1, "}\n")
 
-				methodBody.WriteIndentedString(2, arg.BindStmt(engine)+"\n")
-			}
+		if config.EmitAsync {
 			body.WriteString("\n")
-			body.WriteIndentedString(1, ") throws SQLException {\n")
-		} else {
-			body.WriteString(") throws SQLException {\n")
+			body.writeAsyncMethod(methodModifiers, config, q, t, nonNullAnnotation, nullableAnnotation)
 		}
 
-		completeMethodBody(methodBody, q, embeddedModels)
-		body.WriteString(methodBody.String())
-		body.WriteIndentedString(1, "}\n")
-	}
-	body.WriteString("}\n")
+		if q.Command == core.Many && q.Stream {
+			body.WriteString("\n")
+			body.writeMethodSignature(methodModifiers, streamReturnType(q, t), streamMethodName(q), q, " throws SQLException", t, nonNullAnnotation, nullableAnnotation)
+			body.WriteString(" {\n")
 
-	// sort alphabetically and remove duplicate imports
-	slices.Sort(imports)
-	imports = slices.Compact(imports)
-	for _, imp := range imports {
-		if imp == "" {
-			continue
+			methodBody := NewIndentStringBuilder(config.IndentChar, config.CharsPerIndentLevel)
+			writeStreamMethodBody(methodBody, config, engine, q, embeddedModels, t)
+			body.WriteString(methodBody.String())
+			body.WriteIndentedString(1, "}\n")
 		}
-
-		header.WriteString("import " + imp + ";\n")
 	}
-
-	return className + ".java", []byte(header.String() + body.String()), nil
+	return finishQueriesClass(className, header, body, t)
 }
@@ -0,0 +1,745 @@
+package codegen
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/tandemdude/sqlc-gen-java/internal/core"
+)
+
+var unmanagedResourceRegexp = regexp.MustCompile(`(?m)^\s*var (stmt|results) =`)
+
+var testConfig = core.Config{
+	Package:             "com.example",
+	IndentChar:          " ",
+	CharsPerIndentLevel: 4,
+	NullableAnnotation:  "org.jspecify.annotations.Nullable",
+	NonNullAnnotation:   "org.jspecify.annotations.NonNull",
+	StreamFetchSize:     1000,
+}
+
+func testQuery(command core.QueryCommand, rawCommand string) core.Query {
+	return core.Query{
+		RawCommand:   rawCommand,
+		Command:      command,
+		Text:         "SELECT id FROM foo WHERE id = ?",
+		RawQueryName: "Foo",
+		MethodName:   "foo",
+		Args: []core.QueryArg{
+			{Number: 1, Name: "id", Column: "id", JavaType: core.JavaType{SqlType: "int", Type: "Integer"}},
+		},
+		Returns: []core.QueryReturn{
+			{Name: "id", JavaType: core.JavaType{SqlType: "int", Type: "Integer"}},
+		},
+		InsertIntoTable: "foo",
+	}
+}
+
+// buildMethod generates the queries file for the given query and returns the generated method body.
+func buildMethod(t *testing.T, engine string, q core.Query) string {
+	t.Helper()
+
+	_, contents, err := BuildQueriesFile(engine, testConfig, "queries.sql", []core.Query{q}, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(contents)
+	start := strings.Index(out, " "+q.MethodName+"(")
+	if start == -1 {
+		t.Fatalf("method %s not found in output:\n%s", q.MethodName, out)
+	}
+	return out[start:]
+}
+
+// assertInOrder checks that each of the expected lines appears in the output, in the given order.
+func assertInOrder(t *testing.T, out string, expected []string) {
+	t.Helper()
+
+	remaining := out
+	for _, line := range expected {
+		idx := strings.Index(remaining, line)
+		if idx == -1 {
+			t.Fatalf("expected '%s' (in order) in output:\n%s", line, out)
+		}
+		remaining = remaining[idx+len(line):]
+	}
+}
+
+func TestMethodsCloseStatementAndResults(t *testing.T) {
+	cases := []struct {
+		Command    core.QueryCommand
+		RawCommand string
+		Expected   []string
+	}{
+		{core.One, ":one", []string{
+			"        try (var stmt = conn.prepareStatement(foo)) {\n",
+			"            stmt.setInt(1, id);\n",
+			"            try (var results = stmt.executeQuery()) {\n",
+			"                return Optional.of(ret);\n",
+			"            }\n",
+			"        }\n",
+			"    }\n",
+		}},
+		{core.Many, ":many", []string{
+			"        try (var stmt = conn.prepareStatement(foo)) {\n",
+			"            try (var results = stmt.executeQuery()) {\n",
+			"                while (results.next()) {\n",
+			"                return retList;\n",
+			"            }\n",
+			"        }\n",
+			"    }\n",
+		}},
+		{core.Exec, ":exec", []string{
+			"        try (var stmt = conn.prepareStatement(foo)) {\n",
+			"            stmt.execute();\n",
+			"        }\n",
+			"    }\n",
+		}},
+		{core.ExecRows, ":execrows", []string{
+			"        try (var stmt = conn.prepareStatement(foo)) {\n",
+			"            stmt.execute();\n",
+			"            return stmt.getUpdateCount();\n",
+			"        }\n",
+			"    }\n",
+		}},
+		{core.ExecResult, ":execresult", []string{
+			"        try (var stmt = conn.prepareStatement(foo, java.sql.Statement.RETURN_GENERATED_KEYS)) {\n",
+			"            stmt.execute();\n",
+			"            try (var results = stmt.getGeneratedKeys()) {\n",
+			"                return results.getLong(1);\n",
+			"            }\n",
+			"        }\n",
+			"    }\n",
+		}},
+		{core.CopyFrom, ":copyfrom", []string{
+			"        try (var stmt = conn.prepareStatement(foo)) {\n",
+			"                stmt.addBatch();\n",
+			"                    inserted += countUpdated(stmt.executeBatch());\n",
+			"            return inserted;\n",
+			"        }\n",
+			"    }\n",
+		}},
+	}
+
+	for _, engine := range []string{"postgresql", "mysql", "sqlite"} {
+		for _, c := range cases {
+			out := buildMethod(t, engine, testQuery(c.Command, c.RawCommand))
+
+			if unmanagedResourceRegexp.MatchString(out) {
+				t.Errorf("%s %s: statement or result set not managed by try-with-resources:\n%s", engine, c.RawCommand, out)
+			}
+			assertInOrder(t, out, c.Expected)
+		}
+	}
+}
+
+func TestCopyFromPostgresCancelsCopyOnFailure(t *testing.T) {
+	_, contents, err := BuildQueriesFile("postgresql", testConfig, "queries.sql", []core.Query{testQuery(core.CopyFrom, ":copyfrom")}, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(contents)
+	if strings.Contains(out, "org.postgresql.PGConnection.class") || strings.Contains(out, "import org.postgresql") {
+		t.Errorf("expected pgjdbc not to be referenced at compile time:\n%s", out)
+	}
+	assertInOrder(t, out, []string{
+		"            pgConnection = Class.forName(\"org.postgresql.PGConnection\");\n",
+		"        return conn.isWrapperFor(pgConnection) ? conn.unwrap(pgConnection) : null;\n",
+		"            try {\n",
+		"                return (long) copyInType.getMethod(\"endCopy\").invoke(copyIn);\n",
+		"            } finally {\n",
+		"                    copyInType.getMethod(\"cancelCopy\").invoke(copyIn);\n",
+		"            if (e.getCause() instanceof SQLException) throw (SQLException) e.getCause();\n",
+		"    private static final int COPY_BATCH_SIZE = 1000;\n",
+		"        var pgConn = unwrapPgConnection(conn);\n",
+		"            return copyIn(pgConn, fooCopy, params, (line, row) -> {\n                appendCopyField(line, row.id());\n            });\n",
+		"        try (var stmt = conn.prepareStatement(foo)) {\n",
+		"                if (++batched == COPY_BATCH_SIZE) {\n",
+		"            if (batched > 0) {\n",
+	})
+}
+
+func TestCopyFromHelpersWrittenPerFile(t *testing.T) {
+	cases := []struct {
+		Engine   string
+		Query    core.Query
+		Expected []string
+		Absent   []string
+	}{
+		{"postgresql", testQuery(core.One, ":one"), nil, []string{"appendCopyField", "copyIn(", "countUpdated"}},
+		{"postgresql", testQuery(core.CopyFrom, ":copyfrom"), []string{"appendCopyField", "copyIn(", "countUpdated"}, nil},
+		{"mysql", testQuery(core.CopyFrom, ":copyfrom"), []string{"countUpdated"}, []string{"appendCopyField", "copyIn("}},
+	}
+
+	for _, c := range cases {
+		_, contents, err := BuildQueriesFile(c.Engine, testConfig, "queries.sql", []core.Query{c.Query}, core.EmbeddedModels{}, core.NullableHelpers{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := string(contents)
+		for _, expected := range c.Expected {
+			if !strings.Contains(out, expected) {
+				t.Errorf("%s %s: expected '%s' in output:\n%s", c.Engine, c.Query.RawCommand, expected, out)
+			}
+		}
+		for _, absent := range c.Absent {
+			if strings.Contains(out, absent) {
+				t.Errorf("%s %s: expected no '%s' in output:\n%s", c.Engine, c.Query.RawCommand, absent, out)
+			}
+		}
+	}
+}
+
+func TestEmitInterface(t *testing.T) {
+	conf := testConfig
+	conf.EmitInterface = true
+	queries := []core.Query{testQuery(core.Many, ":many"), testQuery(core.CopyFrom, ":copyfrom")}
+	queries[1].MethodName = "copyFoo"
+
+	name, contents, err := BuildQuerierFile(conf, "users.sql", queries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "UsersQuerier.java" {
+		t.Errorf("expected 'UsersQuerier.java', got '%s'", name)
+	}
+	assertInOrder(t, string(contents), []string{
+		"public interface UsersQuerier {\n",
+		"    List<Integer> foo(\n        int id\n    ) throws SQLException;\n",
+		// records are nested in the interface, so that it does not depend on the queries class
+		"    public record CopyFooParams(\n        int id\n    ) {}\n",
+		"    long copyFoo(\n        @NonNull Iterable<CopyFooParams> params\n    ) throws SQLException;\n",
+		"}\n",
+	})
+	if strings.Contains(string(contents), "UsersQueries") {
+		t.Errorf("expected the interface not to reference the queries class:\n%s", contents)
+	}
+
+	_, contents, err = BuildQueriesFile("postgresql", conf, "users.sql", queries, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertInOrder(t, string(contents), []string{
+		"public class UsersQueries implements UsersQuerier {\n",
+		"    @Override\n    public List<Integer> foo(\n",
+		"    @Override\n    public long copyFoo(\n",
+	})
+	if strings.Contains(string(contents), "record CopyFooParams") {
+		t.Errorf("expected the records to be inherited from the interface:\n%s", contents)
+	}
+}
+
+func TestSliceArgsExpandedAtRuntime(t *testing.T) {
+	q := testQuery(core.Many, ":many")
+	q.Text = "SELECT id FROM foo WHERE name = ? AND id IN (/*SLICE:ids*/?) AND bar = ?"
+	q.Args = []core.QueryArg{
+		{Number: 1, Name: "name", Column: "name", JavaType: core.JavaType{SqlType: "text", Type: "String"}},
+		{Number: 2, Name: "ids", Column: "ids", JavaType: core.JavaType{SqlType: "int", Type: "Integer", IsList: true}, IsSlice: true},
+		{Number: 3, Name: "bar", Column: "bar", JavaType: core.JavaType{SqlType: "int", Type: "Integer"}},
+	}
+
+	out := buildMethod(t, "mysql", q)
+	assertInOrder(t, out, []string{
+		"        @NonNull List<Integer> ids,\n",
+		"        var query = foo\n",
+		"            .replace(\"/*SLICE:ids*/?\", ids.isEmpty() ? \"NULL\" : String.join(\",\", java.util.Collections.nCopies(ids.size(), \"?\")));\n",
+		"        try (var stmt = conn.prepareStatement(query)) {\n",
+		"            var idx = 1;\n",
+		"            stmt.setString(idx++, name);\n",
+		"            for (var elem : ids) {\n",
+		"                java.util.Objects.requireNonNull(elem, \"ids must not contain null elements\");\n",
+		"                stmt.setInt(idx++, elem);\n",
+		"            }\n",
+		"            stmt.setInt(idx++, bar);\n",
+	})
+}
+
+func TestParamsRecordUsedWhenLimitExceeded(t *testing.T) {
+	q := testQuery(core.One, ":one")
+	q.UseParamsRecord = true
+
+	out := buildMethod(t, "postgresql", q)
+	assertInOrder(t, out, []string{
+		" foo(\n        @NonNull FooParams params\n    ) throws SQLException {\n",
+		"            stmt.setInt(1, params.id());\n",
+	})
+
+	_, contents, err := BuildQueriesFile("postgresql", testConfig, "queries.sql", []core.Query{q}, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertInOrder(t, string(contents), []string{
+		"    public record FooParams(\n        int id\n    ) {}\n",
+		"    public Optional<Integer> foo(\n",
+	})
+}
+
+func TestRepeatedPlaceholdersBindEveryPosition(t *testing.T) {
+	q := testQuery(core.One, ":one")
+	q.Text = "SELECT id FROM foo WHERE (id = ? OR parent_id = ?) AND name = ? AND id <> ?"
+	q.Args = []core.QueryArg{
+		{Number: 1, Name: "id", Column: "id", JavaType: core.JavaType{SqlType: "int", Type: "Integer"}},
+		{Number: 2, Name: "name", Column: "name", JavaType: core.JavaType{SqlType: "text", Type: "String"}},
+		{Number: 3, Name: "excluded", Column: "id", JavaType: core.JavaType{SqlType: "int", Type: "Integer"}},
+	}
+	q.Placeholders = []int{1, 1, 2, 3}
+
+	out := buildMethod(t, "postgresql", q)
+	assertInOrder(t, out, []string{
+		"            stmt.setInt(1, id);\n",
+		"            stmt.setInt(2, id);\n",
+		"            stmt.setString(3, name);\n",
+		"            stmt.setInt(4, excluded);\n",
+	})
+}
+
+func TestReorderedPlaceholdersBindByPosition(t *testing.T) {
+	q := testQuery(core.Exec, ":exec")
+	q.Text = "UPDATE foo SET name = ? WHERE id = ?"
+	q.Args = []core.QueryArg{
+		{Number: 1, Name: "id", Column: "id", JavaType: core.JavaType{SqlType: "int", Type: "Integer"}},
+		{Number: 2, Name: "name", Column: "name", JavaType: core.JavaType{SqlType: "text", Type: "String"}},
+	}
+	q.Placeholders = []int{2, 1}
+
+	out := buildMethod(t, "postgresql", q)
+	assertInOrder(t, out, []string{
+		" foo(\n        int id,\n        @NonNull String name\n    ) throws SQLException {\n",
+		"            stmt.setString(1, name);\n",
+		"            stmt.setInt(2, id);\n",
+	})
+}
+
+func TestStreamVariantGenerated(t *testing.T) {
+	q := testQuery(core.Many, ":many")
+	q.Stream = true
+
+	for _, engine := range []string{"postgresql", "mysql", "sqlite"} {
+		_, contents, err := BuildQueriesFile(engine, testConfig, "queries.sql", []core.Query{q}, core.EmbeddedModels{}, core.NullableHelpers{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fetchSize := "1000"
+		if engine == "mysql" {
+			fetchSize = "Integer.MIN_VALUE"
+		}
+
+		out := string(contents)
+		assertInOrder(t, out, []string{
+			"import java.util.stream.Stream;\n",
+			"    private <T> Stream<T> streamResults(@NonNull String query, @NonNull StatementBinder binder, @NonNull RowMapper<T> mapper) throws SQLException {\n",
+			"            stmt.setFetchSize(" + fetchSize + ");\n",
+			"    public List<Integer> foo(\n",
+			"    public Stream<Integer> fooStream(\n        int id\n    ) throws SQLException {\n",
+			"        return streamResults(foo, stmt -> {\n",
+			"            stmt.setInt(1, id);\n",
+			"        }, results -> {\n",
+			"            var ret = results.getInt(1);\n",
+			"            return ret;\n",
+			"        });\n",
+		})
+
+		disablesAutoCommit := strings.Contains(out, "var restoreAutoCommit = conn.getAutoCommit();")
+		if disablesAutoCommit != (engine == "postgresql") {
+			t.Errorf("%s: expected autocommit to be disabled only for postgresql, got %v", engine, disablesAutoCommit)
+		}
+	}
+}
+
+func TestStreamHelpersOmittedWithoutStreamQueries(t *testing.T) {
+	_, contents, err := BuildQueriesFile("postgresql", testConfig, "queries.sql", []core.Query{testQuery(core.Many, ":many")}, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(contents), "Stream") {
+		t.Errorf("expected no streaming variant in output:\n%s", contents)
+	}
+}
+
+func TestBatchCommands(t *testing.T) {
+	cases := []struct {
+		Command        core.QueryCommand
+		RawCommand     string
+		BatchReturning bool
+		Expected       []string
+	}{
+		{core.BatchExec, ":batchexec", false, []string{
+			"    public int[] foo(\n        @NonNull List<FooParams> params\n    ) throws SQLException {\n",
+			"        try (var stmt = conn.prepareStatement(foo)) {\n",
+			"            for (var row : params) {\n",
+			"                stmt.setInt(1, row.id());\n",
+			"                stmt.addBatch();\n",
+			"            return stmt.executeBatch();\n",
+			"        }\n",
+		}},
+		{core.BatchOne, ":batchone", false, []string{
+			"    public List<Optional<Integer>> foo(\n        @NonNull List<FooParams> params\n    ) throws SQLException {\n",
+			"        try (var stmt = conn.prepareStatement(foo)) {\n",
+			"            var retList = new ArrayList<Optional<Integer>>(params.size());\n",
+			"            for (var row : params) {\n",
+			"                stmt.setInt(1, row.id());\n",
+			"                try (var results = stmt.executeQuery()) {\n",
+			"                    if (!results.next()) {\n",
+			"                        retList.add(Optional.empty());\n",
+			"                        continue;\n",
+			"                    if (results.next()) {\n",
+			"                    retList.add(Optional.of(ret));\n",
+			"            return retList;\n",
+		}},
+		{core.BatchMany, ":batchmany", false, []string{
+			"    public List<List<Integer>> foo(\n        @NonNull List<FooParams> params\n    ) throws SQLException {\n",
+			"        try (var stmt = conn.prepareStatement(foo)) {\n",
+			"            var retList = new ArrayList<List<Integer>>(params.size());\n",
+			"                try (var results = stmt.executeQuery()) {\n",
+			"                    var rows = new ArrayList<Integer>();\n",
+			"                    while (results.next()) {\n",
+			"                        rows.add(ret);\n",
+			"                    retList.add(rows);\n",
+			"            return retList;\n",
+		}},
+		{core.BatchOne, ":batchone", true, []string{
+			"    public List<Optional<Integer>> foo(\n        @NonNull List<FooParams> params\n    ) throws SQLException {\n",
+			"        try (var stmt = conn.prepareStatement(foo, java.sql.Statement.RETURN_GENERATED_KEYS)) {\n",
+			"            for (var row : params) {\n",
+			"                stmt.setInt(1, row.id());\n",
+			"                stmt.addBatch();\n",
+			"            var counts = stmt.executeBatch();\n",
+			"            var retList = new ArrayList<Optional<Integer>>(counts.length);\n",
+			"            try (var results = stmt.getGeneratedKeys()) {\n",
+			"                for (var reported : counts) {\n",
+			"                    var count = reported == java.sql.Statement.SUCCESS_NO_INFO ? 1 : reported;\n",
+			"                    if (count == 0) {\n",
+			"                        retList.add(Optional.empty());\n",
+			"                    if (count > 1) {\n",
+			"                    retList.add(Optional.of(ret));\n",
+			"            return retList;\n",
+		}},
+		{core.BatchMany, ":batchmany", true, []string{
+			"    public List<List<Integer>> foo(\n        @NonNull List<FooParams> params\n    ) throws SQLException {\n",
+			"        try (var stmt = conn.prepareStatement(foo, java.sql.Statement.RETURN_GENERATED_KEYS)) {\n",
+			"                stmt.addBatch();\n",
+			"            var counts = stmt.executeBatch();\n",
+			"            var retList = new ArrayList<List<Integer>>(counts.length);\n",
+			"            try (var results = stmt.getGeneratedKeys()) {\n",
+			"                    var count = reported == java.sql.Statement.SUCCESS_NO_INFO ? 1 : reported;\n",
+			"                    var rows = new ArrayList<Integer>(count);\n",
+			"                    for (var i = 0; i < count; i++) {\n",
+			"                        rows.add(ret);\n",
+			"                    retList.add(rows);\n",
+			"            return retList;\n",
+		}},
+	}
+
+	for _, c := range cases {
+		q := testQuery(c.Command, c.RawCommand)
+		q.BatchReturning = c.BatchReturning
+
+		_, contents, err := BuildQueriesFile("postgresql", testConfig, "queries.sql", []core.Query{q}, core.EmbeddedModels{}, core.NullableHelpers{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := string(contents)
+		if unmanagedResourceRegexp.MatchString(out) {
+			t.Errorf("%s: statement or result set not managed by try-with-resources:\n%s", c.RawCommand, out)
+		}
+		assertInOrder(t, out, append([]string{"    public record FooParams(\n        int id\n    ) {}\n"}, c.Expected...))
+	}
+}
+
+func TestNullableEmbeddedModelOnlyConstructedForMatchingRow(t *testing.T) {
+	user, token := "User", "Token"
+	embeddedModels := core.EmbeddedModels{
+		user: {
+			{Name: "userId", JavaType: core.JavaType{SqlType: "int", Type: "Integer"}},
+		},
+		token: {
+			{Name: "token", JavaType: core.JavaType{SqlType: "text", Type: "String", IsNullable: true}},
+			{Name: "tokenId", JavaType: core.JavaType{SqlType: "int", Type: "Integer"}},
+		},
+	}
+
+	q := testQuery(core.One, ":one")
+	q.Returns = []core.QueryReturn{
+		{Name: "user", JavaType: core.JavaType{Type: "com.example.models.User"}, EmbeddedModel: &user},
+		{Name: "token", JavaType: core.JavaType{Type: "com.example.models.Token", IsNullable: true}, EmbeddedModel: &token},
+	}
+
+	_, contents, err := BuildQueriesFile("postgresql", testConfig, "queries.sql", []core.Query{q}, embeddedModels, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertInOrder(t, string(contents), []string{
+		"        @NonNull User user,\n        @Nullable Token token\n",
+		"                var ret = new FooRow(\n",
+		"                    new User(\n                        results.getInt(1)\n                    ),\n",
+		"                    results.getObject(3) == null ? null : new Token(\n",
+		"                        results.getString(2),\n                        results.getInt(3)\n",
+		"                return Optional.of(ret);\n",
+	})
+
+	// without a non-null column, every column of the model must be checked
+	embeddedModels[token][1].JavaType.IsNullable = true
+	q.Returns = q.Returns[1:]
+
+	_, contents, err = BuildQueriesFile("postgresql", testConfig, "queries.sql", []core.Query{q}, embeddedModels, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertInOrder(t, string(contents), []string{
+		"                var ret = results.getObject(1) == null && results.getObject(2) == null ? null : new Token(\n",
+		"                return Optional.ofNullable(ret);\n",
+	})
+}
+
+func TestCollidingTypesUseQualifiedNames(t *testing.T) {
+	q := testQuery(core.One, ":one")
+	q.Returns = []core.QueryReturn{
+		{Name: "status", JavaType: core.JavaType{SqlType: "status", Type: "com.example.enums.Status", IsEnum: true}},
+		{Name: "other", JavaType: core.JavaType{SqlType: "status", Type: "com.example.other.Status"}},
+	}
+
+	_, contents, err := BuildQueriesFile("postgresql", testConfig, "queries.sql", []core.Query{q}, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(contents)
+	if strings.Contains(out, "import com.example.other.Status;") {
+		t.Errorf("expected colliding type not to be imported:\n%s", out)
+	}
+	assertInOrder(t, out, []string{
+		"import com.example.enums.Status;",
+		"@NonNull Status status",
+		"com.example.other.@NonNull Status other",
+		"Status.fromValue(results.getString(1))",
+		"results.getObject(2, com.example.other.Status.class)",
+	})
+}
+
+func TestDataSourceConstructor(t *testing.T) {
+	conf := testConfig
+	conf.EmitDataSourceConstructor = true
+	conf.ExposeConnection = true
+	q := testQuery(core.Many, ":many")
+	q.Stream = true
+
+	_, contents, err := BuildQueriesFile("postgresql", conf, "queries.sql", []core.Query{q}, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(contents)
+	assertInOrder(t, out, []string{
+		"    public Queries(java.sql.Connection conn) {\n        this.conn = conn;\n        this.dataSource = null;\n    }\n",
+		"    public Queries(javax.sql.DataSource dataSource) {\n        this.conn = null;\n        this.dataSource = dataSource;\n    }\n",
+		"    public java.sql.Connection getConn() {return this.conn;}\n",
+		"    public javax.sql.DataSource getDataSource() {return this.dataSource;}\n",
+		"        return conn != null ? conn : dataSource.getConnection();\n",
+		"        var conn = acquireConnection();\n",
+		"                    closeStream(conn, openStmt, restoreAutoCommit);\n",
+		"                releaseConnection(conn);\n",
+		"    public List<Integer> foo(\n        int id\n    ) throws SQLException {\n",
+		"        var conn = acquireConnection();\n        try {\n            try (var stmt = conn.prepareStatement(foo)) {\n",
+		"        } finally {\n            releaseConnection(conn);\n        }\n    }\n",
+	})
+
+	_, contents, err = BuildQueriesFile("postgresql", testConfig, "queries.sql", []core.Query{q}, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(contents), "DataSource") || strings.Contains(string(contents), "acquireConnection") {
+		t.Errorf("expected no data source support in output:\n%s", contents)
+	}
+}
+
+func TestTransactionHelpers(t *testing.T) {
+	_, contents, err := BuildQueriesFile("postgresql", testConfig, "users.sql", []core.Query{testQuery(core.Many, ":many")}, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertInOrder(t, string(contents), []string{
+		"        T apply(@NonNull UsersQueries queries) throws SQLException;\n",
+		"        void accept(@NonNull UsersQueries queries) throws SQLException;\n",
+		"    public <T> T withTransaction(@NonNull TransactionFunction<T> fn) throws SQLException {\n",
+		"    public <T> T withTransaction(int isolationLevel, boolean readOnly, @NonNull TransactionFunction<T> fn) throws SQLException {\n",
+		"    public void inTransaction(@NonNull TransactionBody body) throws SQLException {\n",
+		"    public void inTransaction(int isolationLevel, boolean readOnly, @NonNull TransactionBody body) throws SQLException {\n",
+		// nested transactions use a savepoint
+		"        if (!conn.getAutoCommit()) {\n            var savepoint = conn.setSavepoint();\n",
+		"                conn.rollback(savepoint);\n",
+		"        conn.setAutoCommit(false);\n",
+		"            var ret = fn.apply(new UsersQueries(conn));\n            conn.commit();\n",
+		"                conn.rollback();\n",
+		"        } finally {\n            conn.setAutoCommit(true);\n",
+	})
+
+	conf := testConfig
+	conf.EmitDataSourceConstructor = true
+	_, contents, err = BuildQueriesFile("postgresql", conf, "users.sql", []core.Query{testQuery(core.Many, ":many")}, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the transaction must hold the same borrowed connection throughout
+	assertInOrder(t, string(contents), []string{
+		"    private <T> T runTransaction(@Nullable Integer isolationLevel, boolean readOnly, @NonNull TransactionFunction<T> fn) throws SQLException {\n        var conn = acquireConnection();\n",
+		"            releaseConnection(conn);\n",
+	})
+}
+
+func TestAsyncVariantsGenerated(t *testing.T) {
+	conf := testConfig
+	conf.EmitAsync = true
+	conf.EmitInterface = true
+	queries := []core.Query{testQuery(core.Many, ":many"), testQuery(core.Exec, ":exec")}
+	queries[1].MethodName = "execFoo"
+
+	_, contents, err := BuildQueriesFile("postgresql", conf, "users.sql", queries, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertInOrder(t, string(contents), []string{
+		"import java.util.concurrent.CompletableFuture;\n",
+		"import java.util.concurrent.CompletionException;\n",
+		"import java.util.concurrent.Executor;\n",
+		"    public List<Integer> foo(\n",
+		"    public CompletableFuture<List<Integer>> fooAsync(\n        int id,\n        @NonNull Executor executor\n    ) {\n",
+		"        return CompletableFuture.supplyAsync(() -> {\n",
+		"                return foo(id);\n",
+		"            } catch (SQLException e) {\n                throw new CompletionException(e);\n",
+		"        }, executor);\n",
+		"    public CompletableFuture<Void> execFooAsync(\n",
+		"        return CompletableFuture.runAsync(() -> {\n",
+		"                execFoo(id);\n",
+	})
+
+	_, contents, err = BuildQuerierFile(conf, "users.sql", queries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertInOrder(t, string(contents), []string{
+		"    List<Integer> foo(\n        int id\n    ) throws SQLException;\n",
+		"    CompletableFuture<List<Integer>> fooAsync(\n        int id,\n        @NonNull Executor executor\n    );\n",
+		"    CompletableFuture<Void> execFooAsync(\n",
+	})
+
+	conf.EmitInterface = false
+	conf.Backend = core.BackendSpringJdbc
+	_, contents, err = BuildSpringQueriesFile("postgresql", conf, "users.sql", queries, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertInOrder(t, string(contents), []string{
+		"        return CompletableFuture.supplyAsync(() -> foo(id), executor);\n",
+		"        return CompletableFuture.runAsync(() -> execFoo(id), executor);\n",
+	})
+	if strings.Contains(string(contents), "CompletionException") {
+		t.Error("expected no CompletionException for the spring-jdbc backend")
+	}
+}
+
+func TestClassModelStyle(t *testing.T) {
+	conf := testConfig
+	conf.ModelStyle = core.ModelStyleClass
+
+	q := testQuery(core.BatchMany, ":batchmany")
+	q.Text = "SELECT id, \"name\" FROM foo WHERE id = ?"
+	q.Returns = append(q.Returns, core.QueryReturn{Name: "name", JavaType: core.JavaType{SqlType: "text", Type: "String", IsNullable: true}})
+
+	_, contents, err := BuildQueriesFile("postgresql", conf, "queries.sql", []core.Query{q}, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(contents)
+	if strings.Contains(out, `"""`) || strings.Contains(out, " record ") {
+		t.Errorf("expected no text blocks or records:\n%s", out)
+	}
+	assertInOrder(t, out, []string{
+		"    private static final String foo =\n        \"-- name: Foo :batchmany\\n\" +\n        \"SELECT id, \\\"name\\\" FROM foo WHERE id = ?\\n\";\n",
+		"    public static final class FooRow {\n        private final int id;\n        private final @Nullable String name;\n",
+		"        public FooRow(\n            int id,\n            @Nullable String name\n        ) {\n",
+		"        public @Nullable String getName() {\n            return this.name;\n        }\n",
+		"            return this.id == that.id\n                && Objects.equals(this.name, that.name);\n",
+		"            return Objects.hash(this.id, this.name);\n",
+		"            return \"FooRow[id=\" + this.id + \", name=\" + this.name + \"]\";\n",
+		"    public static final class FooParams {\n",
+		"stmt.setInt(1, row.getId());\n",
+	})
+
+	model := []core.QueryReturn{
+		{Name: "score", JavaType: core.JavaType{SqlType: "float8", Type: "Double"}},
+	}
+	_, contents, err = BuildModelFile(conf, "result", model, []string{"Result"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertInOrder(t, string(contents), []string{
+		"public final class Result {\n",
+		"    public double getScore() {\n",
+		"        return Double.compare(this.score, that.score) == 0;\n",
+	})
+
+	_, contents, err = BuildModelFile(conf, "empty", nil, []string{"Empty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertInOrder(t, string(contents), []string{
+		"    public Empty() {\n    }\n",
+		"        if (!(o instanceof Empty)) return false;\n\n        return true;\n    }\n",
+		"        return 0;\n",
+		"        return \"Empty[]\";\n",
+	})
+}
+
+func TestBuildersGenerated(t *testing.T) {
+	conf := testConfig
+	conf.EmitBuilders = true
+
+	q := testQuery(core.BatchMany, ":batchmany")
+	q.Returns = append(q.Returns, core.QueryReturn{Name: "name", JavaType: core.JavaType{SqlType: "text", Type: "String"}})
+
+	_, contents, err := BuildQueriesFile("postgresql", conf, "queries.sql", []core.Query{q}, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(contents)
+	assertInOrder(t, out, []string{
+		"    public record FooRow(\n        int id,\n        @NonNull String name\n    ) {\n",
+		"        public @NonNull Builder toBuilder() {\n            return new Builder()\n                .id(this.id)\n                .name(this.name);\n",
+		"        public @NonNull FooRow withName(@NonNull String name) {\n            return new FooRow(\n                this.id,\n                name\n            );\n",
+		// primitive fields are boxed so that build() fails if they are unset
+		"        public static final class Builder {\n            private @Nullable Integer id;\n            private @Nullable String name;\n",
+		"            public @NonNull Builder id(int id) {\n",
+		"            public @NonNull Builder name(@NonNull String name) {\n",
+		"                return new FooRow(\n                    Objects.requireNonNull(this.id, \"id\"),\n                    Objects.requireNonNull(this.name, \"name\")\n                );\n",
+		"    public record FooParams(\n        int id\n    ) {}\n",
+	})
+
+	conf.ModelStyle = core.ModelStyleClass
+	model := []core.QueryReturn{
+		{Name: "score", JavaType: core.JavaType{SqlType: "float8", Type: "Double", IsNullable: true}},
+	}
+	_, contents, err = BuildModelFile(conf, "result", model, []string{"Result"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertInOrder(t, string(contents), []string{
+		"public final class Result {\n",
+		"    public String toString() {\n",
+		"    public @NonNull Result withScore(@Nullable Double score) {\n",
+		"    public static final class Builder {\n        private @Nullable Double score;\n",
+		"            return new Result(\n                this.score\n            );\n",
+		"    }\n}\n",
+	})
+
+	if _, _, err := BuildModelFile(conf, "builder", model, []string{"Builder"}); err == nil {
+		t.Error("expected a model named Builder to be rejected")
+	}
+}
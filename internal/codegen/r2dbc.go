@@ -0,0 +1,307 @@
+package codegen
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/tandemdude/sqlc-gen-java/internal/core"
+)
+
+// r2dbcColumns reads the columns of an R2DBC Row named "row", which are indexed from 0.
+type r2dbcColumns struct{}
+
+func (r2dbcColumns) value(ret core.QueryReturn, number int, typeName string) string {
+	idx := number - 1
+
+	if ret.JavaType.Converter != "" {
+		if ret.JavaType.IsNullable {
+			return fmt.Sprintf("java.util.Optional.ofNullable(row.get(%d)).map(%s::fromDatabase).orElse(null)", idx, ret.JavaType.Converter)
+		}
+		return fmt.Sprintf("%s.fromDatabase(row.get(%d))", ret.JavaType.Converter, idx)
+	}
+
+	if ret.JavaType.IsList && ret.JavaType.IsEnum {
+		// enum arrays are read as the string values of their elements
+		mapped := ".map(" + typeName + "::fromValue).toList()"
+		if ret.JavaType.IsNullable {
+			return fmt.Sprintf("java.util.Optional.ofNullable(row.get(%d, String[].class)).map(values -> java.util.Arrays.stream(values)%s).orElse(null)", idx, mapped)
+		}
+		return fmt.Sprintf("java.util.Arrays.stream(row.get(%d, String[].class))%s", idx, mapped)
+	}
+
+	if ret.JavaType.IsList {
+		if ret.JavaType.IsNullable {
+			return fmt.Sprintf("java.util.Optional.ofNullable(row.get(%d, %s[].class)).map(java.util.Arrays::asList).orElse(null)", idx, typeName)
+		}
+		return fmt.Sprintf("java.util.Arrays.asList(row.get(%d, %s[].class))", idx, typeName)
+	}
+
+	if ret.JavaType.IsEnum {
+		if ret.JavaType.IsNullable {
+			return fmt.Sprintf("java.util.Optional.ofNullable(row.get(%d, String.class)).map(%s::fromValue).orElse(null)", idx, typeName)
+		}
+		return fmt.Sprintf("%s.fromValue(row.get(%d, String.class))", typeName, idx)
+	}
+
+	return fmt.Sprintf("row.get(%d, %s.class)", idx, typeName)
+}
+
+func (r2dbcColumns) isNull(number int) string {
+	return fmt.Sprintf("row.get(%d) == null", number-1)
+}
+
+// r2dbcBindStmt generates the statement binding the given value expression to the parameter index given by the index
+// expression. Null values must be bound using Statement#bindNull, so nullable arguments are bound using the generated
+// bindNullable helper.
+func r2dbcBindStmt(arg core.QueryArg, index, value string, t *importTracker) string {
+	typeName := t.Type(arg.JavaType.Type)
+	bound, class := value, typeName+".class"
+
+	switch {
+	case arg.JavaType.Converter != "":
+		bound, class = arg.JavaType.Converter+".toDatabase("+value+")", "Object.class"
+	case arg.JavaType.IsList && arg.JavaType.IsEnum:
+		bound, class = value+".stream().map("+typeName+"::getValue).toArray(String[]::new)", "String[].class"
+	case arg.JavaType.IsList:
+		bound, class = value+".toArray(new "+typeName+"[0])", typeName+"[].class"
+	case arg.JavaType.IsEnum:
+		// enums are bound using their database value, postgres placeholders are cast to the enum type by the query
+		bound, class = value+".getValue()", "String.class"
+	}
+
+	if !arg.JavaType.IsNullable {
+		return fmt.Sprintf("stmt.bind(%s, %s);", index, bound)
+	}
+	if bound != value {
+		bound = value + " == null ? null : " + bound
+	}
+	return fmt.Sprintf("bindNullable(stmt, %s, %s, %s);", index, bound, class)
+}
+
+// r2dbcBindArgs writes the argument bind statements for the given query at the given indent level. R2DBC parameter
+// indexes start from 0, and are computed as each argument is bound if the query contains any sqlc.slice arguments.
+func r2dbcBindArgs(sb *IndentStringBuilder, config core.Config, q core.Query, level int, t *importTracker) {
+	if !hasSliceArgs(q) {
+		for _, binding := range argBindings(q) {
+			sb.WriteIndentedString(level, r2dbcBindStmt(binding.Arg, strconv.Itoa(binding.Index-1), argValue(config, q, binding.Arg), t)+"\n")
+		}
+		return
+	}
+
+	sb.WriteIndentedString(level, "var idx = 0;\n")
+	for _, arg := range q.Args {
+		if !arg.IsSlice {
+			sb.WriteIndentedString(level, r2dbcBindStmt(arg, "idx++", argValue(config, q, arg), t)+"\n")
+			continue
+		}
+
+		elem := arg
+		elem.JavaType.IsList = false
+		elem.JavaType.IsNullable = false
+
+		sb.WriteIndentedString(level, "for (var elem : "+argValue(config, q, arg)+") {\n")
+		writeSliceElementCheck(sb, arg, level+1)
+		sb.WriteIndentedString(level+1, r2dbcBindStmt(elem, "idx++", "elem", t)+"\n")
+		sb.WriteIndentedString(level, "}\n")
+	}
+}
+
+// r2dbcWrapsRow returns whether each row returned by the given query is wrapped in an Optional, as reactive streams
+// cannot emit null values.
+func r2dbcWrapsRow(q core.Query) bool {
+	return len(q.Returns) == 1 && q.Returns[0].JavaType.IsNullable
+}
+
+// r2dbcRowType resolves the type of each element emitted for the rows returned by the given query.
+func r2dbcRowType(q core.Query, t *importTracker) string {
+	if r2dbcWrapsRow(q) {
+		return t.Type("java.util.Optional") + "<" + rowType(q, t) + ">"
+	}
+	return rowType(q, t)
+}
+
+// r2dbcReturnType resolves the return type of the method generated for the given query using the r2dbc backend.
+func r2dbcReturnType(q core.Query, t *importTracker) string {
+	mono, flux := t.Type("reactor.core.publisher.Mono"), t.Type("reactor.core.publisher.Flux")
+
+	switch q.Command {
+	case core.One:
+		return mono + "<" + r2dbcRowType(q, t) + ">"
+	case core.Many:
+		return flux + "<" + r2dbcRowType(q, t) + ">"
+	case core.BatchExec:
+		return flux + "<Long>"
+	case core.BatchOne:
+		return flux + "<" + t.Type("java.util.Optional") + "<" + rowType(q, t) + ">>"
+	case core.BatchMany:
+		return flux + "<" + t.Type("java.util.List") + "<" + r2dbcRowType(q, t) + ">>"
+	default:
+		// :exec, :execrows, :execresult and :copyfrom
+		return mono + "<Long>"
+	}
+}
+
+// writeR2dbcRowMapper writes the mapping of each row of a Result named "result" to the type returned by the given
+// query. The first line continues the current line, and the call is followed by the given suffix.
+func writeR2dbcRowMapper(sb *IndentStringBuilder, suffix string, q core.Query, embeddedModels core.EmbeddedModels, t *importTracker) {
+	sb.WriteString("result.map((row, metadata) -> {\n")
+	createResultRecord(sb, r2dbcColumns{}, 4, q, embeddedModels, t)
+	if r2dbcWrapsRow(q) {
+		sb.WriteIndentedString(4, "return "+t.Type("java.util.Optional")+".ofNullable(ret);\n")
+	} else {
+		sb.WriteIndentedString(4, "return ret;\n")
+	}
+	sb.WriteIndentedString(3, "})"+suffix+"\n")
+}
+
+// writeR2dbcMethodBody writes the body of the method generated for the given query using the r2dbc backend. The
+// statement is only created and executed once the returned publisher is subscribed to.
+func writeR2dbcMethodBody(sb *IndentStringBuilder, config core.Config, q core.Query, embeddedModels core.EmbeddedModels, t *importTracker) {
+	queryText := q.MethodName
+
+	switch {
+	case q.Command == core.CopyFrom:
+		// a statement cannot be executed without any bindings
+		sb.WriteIndentedString(2, "if (!params.iterator().hasNext()) {\n")
+		sb.WriteIndentedString(3, "return "+t.Type("reactor.core.publisher.Mono")+".just(0L);\n")
+		sb.WriteIndentedString(2, "}\n\n")
+	case q.Command.IsBatch():
+		sb.WriteIndentedString(2, "if (params.isEmpty()) {\n")
+		sb.WriteIndentedString(3, "return "+t.Type("reactor.core.publisher.Flux")+".empty();\n")
+		sb.WriteIndentedString(2, "}\n\n")
+	default:
+		queryText = expandSlices(sb, config, q)
+	}
+
+	sb.WriteIndentedString(2, "return withConnection(conn -> {\n")
+	sb.WriteIndentedString(3, "var stmt = conn.createStatement("+queryText+");\n")
+	if q.Command.TakesParamsList() {
+		// each element is bound as a separate set of bindings, producing one result per element
+		sb.WriteIndentedString(3, "var iterator = params.iterator();\n")
+		sb.WriteIndentedString(3, "while (iterator.hasNext()) {\n")
+		sb.WriteIndentedString(4, "var row = iterator.next();\n")
+		r2dbcBindArgs(sb, config, q, 4, t)
+		sb.WriteIndentedString(4, "if (iterator.hasNext()) {\n")
+		sb.WriteIndentedString(5, "stmt.add();\n")
+		sb.WriteIndentedString(4, "}\n")
+		sb.WriteIndentedString(3, "}\n")
+	} else {
+		r2dbcBindArgs(sb, config, q, 3, t)
+	}
+	if q.Command == core.ExecResult {
+		sb.WriteIndentedString(3, "stmt.returnGeneratedValues();\n")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteIndentedString(3, "return "+t.Type("reactor.core.publisher.Flux")+".from(stmt.execute()).concatMap(result -> ")
+	suffix := ";"
+	switch q.Command {
+	case core.One, core.Many:
+		writeR2dbcRowMapper(sb, ");", q, embeddedModels, t)
+		if q.Command == core.One {
+			suffix = ".singleOrEmpty();"
+		}
+	case core.ExecResult:
+		// some drivers return every column of the inserted row, the generated key is always the first
+		sb.WriteString("result.map((row, metadata) -> ((Number) row.get(0)).longValue()));\n")
+		suffix = fmt.Sprintf(
+			".next().switchIfEmpty(%s.error(() -> new %s(\"no generated key returned\")));",
+			t.Type("reactor.core.publisher.Mono"), t.Type("java.util.NoSuchElementException"),
+		)
+	case core.BatchOne:
+		sb.WriteString(t.Type("reactor.core.publisher.Flux") + ".from(")
+		if r2dbcWrapsRow(q) {
+			writeR2dbcRowMapper(sb, ").singleOrEmpty().defaultIfEmpty("+t.Type("java.util.Optional")+".empty()));", q, embeddedModels, t)
+		} else {
+			writeR2dbcRowMapper(sb, ").singleOrEmpty().map("+t.Type("java.util.Optional")+"::of).defaultIfEmpty("+t.Type("java.util.Optional")+".empty()));", q, embeddedModels, t)
+		}
+	case core.BatchMany:
+		sb.WriteString(t.Type("reactor.core.publisher.Flux") + ".from(")
+		writeR2dbcRowMapper(sb, ").collectList());", q, embeddedModels, t)
+	default:
+		sb.WriteString("result.getRowsUpdated());\n")
+		if q.Command != core.BatchExec {
+			suffix = ".reduce(0L, Long::sum);"
+		}
+	}
+	sb.WriteIndentedString(2, "})"+suffix+"\n")
+}
+
+// hasNullableArgs returns whether any of the given queries binds a nullable argument.
+func hasNullableArgs(queries []core.Query) bool {
+	return slices.ContainsFunc(queries, func(q core.Query) bool {
+		return slices.ContainsFunc(q.Args, func(arg core.QueryArg) bool { return arg.JavaType.IsNullable })
+	})
+}
+
+// BuildR2dbcQueriesFile builds the queries class for the given query file using the r2dbc backend. The class can be
+// constructed using either a Connection, which is used to execute every statement, or a ConnectionFactory, from which
+// a connection is created for each method call and closed once the returned publisher terminates.
+func BuildR2dbcQueriesFile(engine string, config core.Config, queryFilename string, queries []core.Query, embeddedModels core.EmbeddedModels, nullableHelpers core.NullableHelpers) (string, []byte, error) {
+	className := QueriesClassName(queryFilename)
+
+	t := newImportTracker(config.Package, queriesPackageTypes(queryFilename, queries)...)
+	t.reserve(queriesJdkTypes...)
+	t.reserve("reactor.core.publisher.Mono", "reactor.core.publisher.Flux", "io.r2dbc.spi.Connection", "io.r2dbc.spi.ConnectionFactory")
+	nonNullAnnotation := t.Annotation(config.NonNullAnnotation)
+	nullableAnnotation := t.Annotation(config.NullableAnnotation)
+
+	header, body, methodModifiers := newQueriesClass(config, queryFilename, t)
+
+	connection := t.Type("io.r2dbc.spi.Connection")
+	connectionFactory := t.Type("io.r2dbc.spi.ConnectionFactory")
+	flux := t.Type("reactor.core.publisher.Flux")
+
+	body.WriteIndentedString(1, "private final "+connection+" conn;\n")
+	body.WriteIndentedString(1, "private final "+connectionFactory+" connectionFactory;\n\n")
+	body.WriteIndentedString(1, "public "+className+"("+connection+" conn) {\n")
+	body.WriteIndentedString(2, "this.conn = conn;\n")
+	body.WriteIndentedString(2, "this.connectionFactory = null;\n")
+	body.WriteIndentedString(1, "}\n\n")
+	body.WriteIndentedString(1, "public "+className+"("+connectionFactory+" connectionFactory) {\n")
+	body.WriteIndentedString(2, "this.conn = null;\n")
+	body.WriteIndentedString(2, "this.connectionFactory = connectionFactory;\n")
+	body.WriteIndentedString(1, "}\n")
+
+	if config.ExposeConnection {
+		body.WriteString("\n")
+		body.WriteIndentedString(1, "public "+connection+" getConn() {return this.conn;}\n")
+		body.WriteIndentedString(1, "public "+connectionFactory+" getConnectionFactory() {return this.connectionFactory;}\n")
+	}
+
+	// helpers to run statements on the appropriate connection, and to bind null values
+	body.WriteString("\n")
+	body.WriteIndentedString(1, "private <T> "+flux+"<T> withConnection("+t.Type("java.util.function.Function")+"<"+connection+", "+t.Type("org.reactivestreams.Publisher")+"<T>> fn) {\n")
+	body.WriteIndentedString(2, "if (conn != null) {\n")
+	body.WriteIndentedString(3, "return "+flux+".from(fn.apply(conn));\n")
+	body.WriteIndentedString(2, "}\n")
+	body.WriteIndentedString(2, "return "+flux+".usingWhen(connectionFactory.create(), fn, "+connection+"::close);\n")
+	body.WriteIndentedString(1, "}\n")
+
+	if hasNullableArgs(queries) {
+		body.WriteString("\n")
+		body.WriteIndentedString(1, "private static void bindNullable("+core.Annotate(t.Type("io.r2dbc.spi.Statement"), nonNullAnnotation)+" stmt, int index, "+core.Annotate("Object", nullableAnnotation)+" value, "+core.Annotate("Class<?>", nonNullAnnotation)+" type) {\n")
+		body.WriteIndentedString(2, "if (value == null) {\n")
+		body.WriteIndentedString(3, "stmt.bindNull(index, type);\n")
+		body.WriteIndentedString(2, "} else {\n")
+		body.WriteIndentedString(3, "stmt.bind(index, value);\n")
+		body.WriteIndentedString(2, "}\n")
+		body.WriteIndentedString(1, "}\n")
+	}
+
+	for _, q := range queries {
+		body.WriteString("\n")
+		body.writeQueryText(config, q)
+		if !config.EmitInterface {
+			body.writeQueryRecords(config, q, t, nonNullAnnotation, nullableAnnotation)
+		}
+
+		body.WriteString("\n")
+		body.writeMethodSignature(methodModifiers, r2dbcReturnType(q, t), q.MethodName, q, "", t, nonNullAnnotation, nullableAnnotation)
+		body.WriteString(" {\n")
+		writeR2dbcMethodBody(body, config, q, embeddedModels, t)
+		body.WriteIndentedString(1, "}\n")
+	}
+	return finishQueriesClass(className, header, body, t)
+}
@@ -0,0 +1,114 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tandemdude/sqlc-gen-java/internal/core"
+)
+
+func TestR2dbcBackend(t *testing.T) {
+	conf := testConfig
+	conf.Backend = core.BackendR2dbc
+	queries := []core.Query{
+		testQuery(core.One, ":one"),
+		testQuery(core.ExecRows, ":execrows"),
+	}
+	queries[0].Text = "SELECT id FROM foo WHERE id = $1"
+	queries[1].MethodName = "bar"
+	queries[1].Args[0].JavaType.IsNullable = true
+
+	_, contents, err := BuildR2dbcQueriesFile("postgresql", conf, "queries.sql", queries, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(contents)
+	assertInOrder(t, out, []string{
+		"    public Queries(Connection conn) {\n",
+		"    public Queries(ConnectionFactory connectionFactory) {\n",
+		"        return Flux.usingWhen(connectionFactory.create(), fn, Connection::close);\n",
+		"            stmt.bindNull(index, type);\n",
+		// the numbered placeholders are supported natively, so the query text is not rewritten
+		"        SELECT id FROM foo WHERE id = $1\n",
+		"    public Mono<Integer> foo(\n        int id\n    ) {\n",
+		"            var stmt = conn.createStatement(foo);\n            stmt.bind(0, id);\n",
+		"            return Flux.from(stmt.execute()).concatMap(result -> result.map((row, metadata) -> {\n                var ret = row.get(0, Integer.class);\n",
+		"        }).singleOrEmpty();\n",
+		"    public Mono<Long> bar(\n        @Nullable Integer id\n    ) {\n",
+		"            bindNullable(stmt, 0, id, Integer.class);\n",
+		"            return Flux.from(stmt.execute()).concatMap(result -> result.getRowsUpdated());\n        }).reduce(0L, Long::sum);\n",
+	})
+	if strings.Contains(out, "SQLException") || strings.Contains(out, "java.sql") {
+		t.Errorf("expected no JDBC types in output:\n%s", out)
+	}
+}
+
+func TestR2dbcNullableRowsWrappedInOptional(t *testing.T) {
+	conf := testConfig
+	conf.Backend = core.BackendR2dbc
+	q := testQuery(core.Many, ":many")
+	q.Returns[0].JavaType.IsNullable = true
+
+	_, contents, err := BuildR2dbcQueriesFile("mysql", conf, "queries.sql", []core.Query{q}, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertInOrder(t, string(contents), []string{
+		"    public Flux<Optional<Integer>> foo(\n",
+		"                return Optional.ofNullable(ret);\n",
+	})
+}
+
+func TestR2dbcEnums(t *testing.T) {
+	conf := testConfig
+	conf.Backend = core.BackendR2dbc
+	q := testQuery(core.Many, ":many")
+	q.Args = []core.QueryArg{
+		{Number: 1, Name: "status", JavaType: core.JavaType{SqlType: "status", Type: "Status", IsEnum: true, IsNullable: true}},
+		{Number: 2, Name: "statuses", JavaType: core.JavaType{SqlType: "status", Type: "Status", IsList: true, IsEnum: true}},
+	}
+	q.Returns = []core.QueryReturn{
+		{Name: "status", JavaType: core.JavaType{SqlType: "status", Type: "Status", IsEnum: true}},
+		{Name: "history", JavaType: core.JavaType{SqlType: "status", Type: "Status", IsList: true, IsEnum: true}},
+	}
+
+	_, contents, err := BuildR2dbcQueriesFile("postgresql", conf, "queries.sql", []core.Query{q}, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertInOrder(t, string(contents), []string{
+		"            bindNullable(stmt, 0, status == null ? null : status.getValue(), String.class);\n",
+		"            stmt.bind(1, statuses.stream().map(Status::getValue).toArray(String[]::new));\n",
+		"                    Status.fromValue(row.get(0, String.class)),\n",
+		"                    java.util.Arrays.stream(row.get(1, String[].class)).map(Status::fromValue).toList()\n",
+	})
+}
+
+func TestR2dbcBatchCommands(t *testing.T) {
+	conf := testConfig
+	conf.Backend = core.BackendR2dbc
+	queries := []core.Query{
+		testQuery(core.BatchOne, ":batchone"),
+		testQuery(core.CopyFrom, ":copyfrom"),
+	}
+	queries[1].MethodName = "bar"
+
+	_, contents, err := BuildR2dbcQueriesFile("postgresql", conf, "queries.sql", queries, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertInOrder(t, string(contents), []string{
+		"    public Flux<Optional<Integer>> foo(\n        @NonNull List<FooParams> params\n    ) {\n",
+		"        if (params.isEmpty()) {\n            return Flux.empty();\n        }\n",
+		"                var row = iterator.next();\n                stmt.bind(0, row.id());\n",
+		"            })).singleOrEmpty().map(Optional::of).defaultIfEmpty(Optional.empty()));\n",
+		"    public Mono<Long> bar(\n        @NonNull Iterable<BarParams> params\n    ) {\n",
+		"        if (!params.iterator().hasNext()) {\n            return Mono.just(0L);\n        }\n",
+		"                if (iterator.hasNext()) {\n                    stmt.add();\n                }\n",
+		"        }).reduce(0L, Long::sum);\n",
+	})
+}
@@ -0,0 +1,239 @@
+package codegen
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/tandemdude/sqlc-gen-java/internal/core"
+)
+
+// needsConnection returns whether binding the arguments of the given query requires the connection, in order to
+// create SQL arrays.
+func needsConnection(q core.Query) bool {
+	return slices.ContainsFunc(q.Args, func(arg core.QueryArg) bool {
+		return arg.JavaType.IsList && !arg.IsSlice && arg.JavaType.Converter == ""
+	})
+}
+
+// writeSpringBinder writes the body of the PreparedStatementSetter binding the arguments of the given query.
+func writeSpringBinder(sb *IndentStringBuilder, config core.Config, engine string, q core.Query, level int) {
+	if needsConnection(q) {
+		sb.WriteIndentedString(level, "var conn = stmt.getConnection();\n")
+	}
+	bindArgs(sb, config, engine, q, level)
+}
+
+// springParamStmt generates the statement binding the given value expression to the parameter index given by the
+// index expression of the JdbcClient StatementSpec named "stmt". Values are converted exactly as they are by the jdbc
+// backend, and null values are bound using the SQL type of the argument where one is known.
+func springParamStmt(engine string, arg core.QueryArg, index, value string, t *importTracker) string {
+	bound, sqlType := value, ""
+
+	switch {
+	case arg.JavaType.Converter != "":
+		bound = arg.JavaType.Converter + ".toDatabase(" + value + ")"
+	case arg.JavaType.IsList:
+		elements := value + ".toArray()"
+		if arg.JavaType.IsEnum {
+			elements = value + ".stream().map(" + t.Type(arg.JavaType.Type) + "::getValue).toArray()"
+		}
+		bound = "new " + t.Type("org.springframework.jdbc.support.SqlArrayValue") + "(\"" + arg.JavaType.SqlType + "\", " + elements + ")"
+		if arg.JavaType.IsNullable {
+			sqlType = "java.sql.Types.ARRAY"
+		}
+	case arg.JavaType.IsEnum:
+		bound = value + ".getValue()"
+		// postgres doesn't accept a varchar for an enum parameter
+		if engine == "postgresql" {
+			sqlType = "java.sql.Types.OTHER"
+		}
+	case core.IsSqliteTemporal(engine, arg.JavaType.Type):
+		bound = value + ".toString()"
+	default:
+		if javaSqlType, ok := arg.JavaType.JavaSqlType(); ok && arg.JavaType.IsNullable {
+			sqlType = "java.sql.Types." + javaSqlType
+		}
+	}
+
+	if arg.JavaType.IsNullable && bound != value {
+		bound = value + " == null ? null : " + bound
+	}
+	if sqlType == "" {
+		return fmt.Sprintf("stmt.param(%s, %s);", index, bound)
+	}
+	return fmt.Sprintf("stmt.param(%s, %s, %s);", index, bound, sqlType)
+}
+
+// writeSpringStatement writes the creation of the JdbcClient StatementSpec named "stmt" for the given query, followed
+// by the statements binding its arguments. If the query contains any sqlc.slice arguments, the parameter indexes are
+// computed as each argument is bound.
+func writeSpringStatement(sb *IndentStringBuilder, config core.Config, engine string, q core.Query, t *importTracker) {
+	queryText := expandSlices(sb, config, q)
+	sb.WriteIndentedString(2, "var stmt = client.sql("+queryText+");\n")
+
+	if !hasSliceArgs(q) {
+		for _, binding := range argBindings(q) {
+			sb.WriteIndentedString(2, springParamStmt(engine, binding.Arg, strconv.Itoa(binding.Index), argValue(config, q, binding.Arg), t)+"\n")
+		}
+		sb.WriteString("\n")
+		return
+	}
+
+	sb.WriteIndentedString(2, "var idx = 1;\n")
+	for _, arg := range q.Args {
+		if !arg.IsSlice {
+			sb.WriteIndentedString(2, springParamStmt(engine, arg, "idx++", argValue(config, q, arg), t)+"\n")
+			continue
+		}
+
+		elem := arg
+		elem.JavaType.IsList = false
+		elem.JavaType.IsNullable = false
+
+		sb.WriteIndentedString(2, "for (var elem : "+argValue(config, q, arg)+") {\n")
+		writeSliceElementCheck(sb, arg, 3)
+		sb.WriteIndentedString(3, springParamStmt(engine, elem, "idx++", "elem", t)+"\n")
+		sb.WriteIndentedString(2, "}\n")
+	}
+	sb.WriteString("\n")
+}
+
+// writeSpringRowMapper writes the query of the StatementSpec named "stmt", mapping each row using the same expressions
+// as the jdbc backend. The result is collected by the given method of the returned MappedQuerySpec, e.g. "list".
+func writeSpringRowMapper(sb *IndentStringBuilder, engine, collect string, q core.Query, embeddedModels core.EmbeddedModels, t *importTracker) {
+	sb.WriteIndentedString(2, "return stmt.query((results, rowNum) -> {\n")
+	createResultRecord(sb, jdbcColumns(engine), 3, q, embeddedModels, t)
+	sb.WriteIndentedString(3, "return ret;\n")
+	sb.WriteIndentedString(2, "})."+collect+"();\n")
+}
+
+// writeSpringBatch writes a call to JdbcOperations#batchUpdate, binding the arguments of each element of the given
+// list. The call is prefixed with the given prefix.
+func writeSpringBatch(sb *IndentStringBuilder, config core.Config, engine, prefix, list string, q core.Query, t *importTracker, nonNullAnnotation string) {
+	sb.WriteIndentedString(2, prefix+"jdbc.batchUpdate("+q.MethodName+", new "+t.Type("org.springframework.jdbc.core.BatchPreparedStatementSetter")+"() {\n")
+	sb.WriteIndentedString(3, "@Override\n")
+	sb.WriteIndentedString(3, "public void setValues("+core.Annotate("java.sql.PreparedStatement", nonNullAnnotation)+" stmt, int i) throws SQLException {\n")
+	sb.WriteIndentedString(4, "var row = "+list+".get(i);\n")
+	writeSpringBinder(sb, config, engine, q, 4)
+	sb.WriteIndentedString(3, "}\n\n")
+	sb.WriteIndentedString(3, "@Override\n")
+	sb.WriteIndentedString(3, "public int getBatchSize() {\n")
+	sb.WriteIndentedString(4, "return "+list+".size();\n")
+	sb.WriteIndentedString(3, "}\n")
+	sb.WriteIndentedString(2, "});\n")
+}
+
+// writeSpringMethodBody writes the body of the method generated for the given query using the spring-jdbc backend.
+// Statements are executed using JdbcClient, or the JdbcOperations it was created from for batches, so that they
+// participate in spring managed transactions and any SQLException is translated into a DataAccessException.
+func writeSpringMethodBody(sb *IndentStringBuilder, config core.Config, engine string, q core.Query, embeddedModels core.EmbeddedModels, t *importTracker, nonNullAnnotation string) {
+	switch q.Command {
+	case core.One:
+		// optional() throws an IncorrectResultSizeDataAccessException if more than one row is returned
+		writeSpringStatement(sb, config, engine, q, t)
+		writeSpringRowMapper(sb, engine, "optional", q, embeddedModels, t)
+	case core.Many:
+		writeSpringStatement(sb, config, engine, q, t)
+		writeSpringRowMapper(sb, engine, "list", q, embeddedModels, t)
+	case core.Exec:
+		writeSpringStatement(sb, config, engine, q, t)
+		sb.WriteIndentedString(2, "stmt.update();\n")
+	case core.ExecRows:
+		writeSpringStatement(sb, config, engine, q, t)
+		sb.WriteIndentedString(2, "return stmt.update();\n")
+	case core.ExecResult:
+		writeSpringStatement(sb, config, engine, q, t)
+		sb.WriteIndentedString(2, "var keyHolder = new "+t.Type("org.springframework.jdbc.support.GeneratedKeyHolder")+"();\n")
+		sb.WriteIndentedString(2, "stmt.update(keyHolder);\n\n")
+		// some drivers return every column of the inserted row, the generated key is always the first
+		sb.WriteIndentedString(2, "var keys = keyHolder.getKeyList();\n")
+		sb.WriteIndentedString(2, "if (keys.isEmpty() || keys.get(0).isEmpty()) {\n")
+		sb.WriteIndentedString(3, "throw new "+t.Type("org.springframework.dao.DataRetrievalFailureException")+"(\"no generated key returned\");\n")
+		sb.WriteIndentedString(2, "}\n")
+		sb.WriteIndentedString(2, "return ((Number) keys.get(0).values().iterator().next()).longValue();\n")
+	case core.CopyFrom:
+		// the rows are inserted in fixed size batches exactly as they are by the jdbc backend
+		sb.WriteIndentedString(2, "return jdbc.execute(("+t.Type("org.springframework.jdbc.core.ConnectionCallback")+"<Long>) conn -> {\n")
+		writeBatchInsert(sb, config, engine, q, 3)
+		sb.WriteIndentedString(2, "});\n")
+	case core.BatchExec:
+		writeSpringBatch(sb, config, engine, "return ", "params", q, t, nonNullAnnotation)
+	case core.BatchOne, core.BatchMany:
+		// batchUpdate cannot return rows, so the batch is executed exactly as it is by the jdbc backend
+		sb.WriteIndentedString(2, "return jdbc.execute(("+t.Type("org.springframework.jdbc.core.ConnectionCallback")+"<"+methodReturnType(q, t)+">) conn -> {\n")
+		writeBatchRows(sb, config, engine, q, 3, embeddedModels, t)
+		sb.WriteIndentedString(2, "});\n")
+	}
+}
+
+// BuildSpringQueriesFile builds the queries class for the given query file using the spring-jdbc backend. The class
+// is constructed using the JdbcOperations configured by spring, e.g. a JdbcTemplate, from which the JdbcClient
+// executing each statement is created.
+func BuildSpringQueriesFile(engine string, config core.Config, queryFilename string, queries []core.Query, embeddedModels core.EmbeddedModels, nullableHelpers core.NullableHelpers) (string, []byte, error) {
+	className := QueriesClassName(queryFilename)
+
+	t := newImportTracker(config.Package, queriesPackageTypes(queryFilename, queries)...)
+	t.reserve(queriesJdkTypes...)
+	nonNullAnnotation := t.Annotation(config.NonNullAnnotation)
+	nullableAnnotation := t.Annotation(config.NullableAnnotation)
+	for _, typ := range []string{"java.sql.SQLException", "java.sql.ResultSet", "java.util.Arrays"} {
+		t.Type(typ)
+	}
+
+	header, body, methodModifiers := newQueriesClass(config, queryFilename, t)
+
+	jdbcOperations := t.Type("org.springframework.jdbc.core.JdbcOperations")
+	jdbcClient := t.Type("org.springframework.jdbc.core.simple.JdbcClient")
+
+	body.WriteIndentedString(1, "private final "+jdbcOperations+" jdbc;\n")
+	body.WriteIndentedString(1, "private final "+jdbcClient+" client;\n\n")
+	body.WriteIndentedString(1, "public "+className+"("+jdbcOperations+" jdbc) {\n")
+	body.WriteIndentedString(2, "this.jdbc = jdbc;\n")
+	body.WriteIndentedString(2, "this.client = "+jdbcClient+".create(jdbc);\n")
+	body.WriteIndentedString(1, "}\n")
+
+	if config.ExposeConnection {
+		body.WriteString("\n")
+		body.WriteIndentedString(1, "public "+jdbcOperations+" getJdbc() {return this.jdbc;}\n")
+		body.WriteIndentedString(1, "public "+jdbcClient+" getClient() {return this.client;}\n")
+	}
+
+	// boilerplate methods to allow for getting null primitive values
+	body.WriteString("\n")
+
+	body.writeNullableHelpers(nullableHelpers, t, nonNullAnnotation, nullableAnnotation)
+
+	if hasCopyFromQueries(queries) {
+		body.writeBatchInsertHelpers()
+	}
+
+	for _, q := range queries {
+		body.WriteString("\n")
+		body.writeQueryText(config, q)
+		if !config.EmitInterface {
+			body.writeQueryRecords(config, q, t, nonNullAnnotation, nullableAnnotation)
+		}
+
+		body.WriteString("\n")
+		body.writeMethodSignature(methodModifiers, methodReturnType(q, t), q.MethodName, q, "", t, nonNullAnnotation, nullableAnnotation)
+		body.WriteString(" {\n")
+		writeSpringMethodBody(body, config, engine, q, embeddedModels, t, nonNullAnnotation)
+		body.WriteIndentedString(1, "}\n")
+
+		if config.EmitAsync {
+			body.WriteString("\n")
+			body.writeAsyncMethod(methodModifiers, config, q, t, nonNullAnnotation, nullableAnnotation)
+		}
+
+		if q.Command == core.Many && q.Stream {
+			body.WriteString("\n")
+			body.writeMethodSignature(methodModifiers, streamReturnType(q, t), streamMethodName(q), q, "", t, nonNullAnnotation, nullableAnnotation)
+			body.WriteString(" {\n")
+			writeSpringStatement(body, config, engine, q, t)
+			writeSpringRowMapper(body, engine, "stream", q, embeddedModels, t)
+			body.WriteIndentedString(1, "}\n")
+		}
+	}
+	return finishQueriesClass(className, header, body, t)
+}
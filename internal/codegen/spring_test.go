@@ -0,0 +1,121 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tandemdude/sqlc-gen-java/internal/core"
+)
+
+func TestSpringJdbcBackend(t *testing.T) {
+	conf := testConfig
+	conf.Backend = core.BackendSpringJdbc
+	conf.ExposeConnection = true
+	queries := []core.Query{
+		testQuery(core.One, ":one"),
+		testQuery(core.ExecRows, ":execrows"),
+	}
+	queries[1].MethodName = "bar"
+
+	_, contents, err := BuildSpringQueriesFile("postgresql", conf, "queries.sql", queries, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(contents)
+	assertInOrder(t, out, []string{
+		"    private final JdbcOperations jdbc;\n    private final JdbcClient client;\n",
+		"    public Queries(JdbcOperations jdbc) {\n        this.jdbc = jdbc;\n        this.client = JdbcClient.create(jdbc);\n    }\n",
+		"    public JdbcOperations getJdbc() {return this.jdbc;}\n    public JdbcClient getClient() {return this.client;}\n",
+		"    public Optional<Integer> foo(\n        int id\n    ) {\n",
+		"        var stmt = client.sql(foo);\n        stmt.param(1, id);\n\n        return stmt.query((results, rowNum) -> {\n",
+		"            return ret;\n        }).optional();\n",
+		"    public int bar(\n        int id\n    ) {\n        var stmt = client.sql(bar);\n        stmt.param(1, id);\n\n        return stmt.update();\n",
+	})
+
+	// exceptions are translated by spring, no checked exceptions are thrown
+	if strings.Contains(out, "throws SQLException") || strings.Contains(out, "java.sql.Connection") {
+		t.Errorf("expected no JDBC connection handling in output:\n%s", out)
+	}
+}
+
+func TestSpringJdbcParams(t *testing.T) {
+	conf := testConfig
+	conf.Backend = core.BackendSpringJdbc
+	q := testQuery(core.Many, ":many")
+	q.Stream = true
+	q.Args = []core.QueryArg{
+		{Number: 1, Name: "count", JavaType: core.JavaType{SqlType: "int4", Type: "Integer", IsNullable: true}},
+		{Number: 2, Name: "status", JavaType: core.JavaType{SqlType: "status", Type: "Status", IsEnum: true}},
+		{Number: 3, Name: "tags", JavaType: core.JavaType{SqlType: "text", Type: "String", IsList: true, IsNullable: true}},
+		{Number: 4, Name: "payload", JavaType: core.JavaType{SqlType: "jsonb", Type: "com.example.Payload", Converter: "com.example.PayloadConverter"}},
+		{Number: 5, Name: "statuses", JavaType: core.JavaType{SqlType: "status", Type: "Status", IsList: true, IsEnum: true}},
+	}
+
+	_, contents, err := BuildSpringQueriesFile("postgresql", conf, "queries.sql", []core.Query{q}, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertInOrder(t, string(contents), []string{
+		"        stmt.param(1, count, java.sql.Types.INTEGER);\n",
+		"        stmt.param(2, status.getValue(), java.sql.Types.OTHER);\n",
+		"        stmt.param(3, tags == null ? null : new SqlArrayValue(\"text\", tags.toArray()), java.sql.Types.ARRAY);\n",
+		"        stmt.param(4, com.example.PayloadConverter.toDatabase(payload));\n",
+		"        stmt.param(5, new SqlArrayValue(\"status\", statuses.stream().map(Status::getValue).toArray()));\n",
+		"        }).list();\n",
+		"        }).stream();\n",
+	})
+
+	q.Args = []core.QueryArg{
+		{Number: 1, Name: "name", JavaType: core.JavaType{SqlType: "text", Type: "String"}},
+		{Number: 2, Name: "ids", JavaType: core.JavaType{SqlType: "int", Type: "Integer", IsList: true}, IsSlice: true},
+	}
+	q.Text = "SELECT id FROM foo WHERE name = ? AND id IN (/*SLICE:ids*/?)"
+	q.Stream = false
+
+	_, contents, err = BuildSpringQueriesFile("mysql", conf, "queries.sql", []core.Query{q}, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertInOrder(t, string(contents), []string{
+		"        var stmt = client.sql(query);\n        var idx = 1;\n        stmt.param(idx++, name);\n",
+		"        for (var elem : ids) {\n",
+		"            stmt.param(idx++, elem);\n        }\n",
+	})
+}
+
+func TestSpringJdbcBatchCommands(t *testing.T) {
+	conf := testConfig
+	conf.Backend = core.BackendSpringJdbc
+	queries := []core.Query{
+		testQuery(core.BatchExec, ":batchexec"),
+		testQuery(core.CopyFrom, ":copyfrom"),
+		testQuery(core.BatchMany, ":batchmany"),
+	}
+	queries[1].MethodName = "bar"
+	queries[2].MethodName = "baz"
+	queries[2].BatchReturning = true
+
+	_, contents, err := BuildSpringQueriesFile("postgresql", conf, "queries.sql", queries, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(contents)
+	assertInOrder(t, out, []string{
+		"        return jdbc.batchUpdate(foo, new BatchPreparedStatementSetter() {\n",
+		"                var row = params.get(i);\n                stmt.setInt(1, row.id());\n",
+		// copyfrom is implemented as a batch insert instead of COPY FROM STDIN
+		"        return jdbc.execute((ConnectionCallback<Long>) conn -> {\n",
+		"            try (var stmt = conn.prepareStatement(bar)) {\n",
+		"                        inserted += countUpdated(stmt.executeBatch());\n",
+		"        return jdbc.execute((ConnectionCallback<List<List<Integer>>>) conn -> {\n",
+		"            try (var stmt = conn.prepareStatement(baz, java.sql.Statement.RETURN_GENERATED_KEYS)) {\n",
+		"                try (var results = stmt.getGeneratedKeys()) {\n",
+	})
+	if strings.Contains(out, "CopyManager") {
+		t.Errorf("expected no COPY FROM STDIN in output:\n%s", out)
+	}
+}
@@ -0,0 +1,308 @@
+package codegen
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tandemdude/sqlc-gen-java/internal/core"
+)
+
+// vertxGetterTypes are the types read using the dedicated getter of a Vert.x Row, e.g. getInteger, which convert
+// between compatible column types.
+var vertxGetterTypes = []string{
+	"Boolean", "Double", "Float", "Integer", "Long", "Short", "String", "BigDecimal", "LocalDate", "LocalDateTime",
+	"LocalTime", "OffsetDateTime", "UUID",
+}
+
+// vertxColumns reads the columns of a Vert.x Row named "row", which are indexed from 0.
+type vertxColumns struct{}
+
+func (vertxColumns) value(ret core.QueryReturn, number int, typeName string) string {
+	idx := number - 1
+	typeOnly := ret.JavaType.Type[strings.LastIndex(ret.JavaType.Type, ".")+1:]
+
+	if ret.JavaType.Converter != "" {
+		if ret.JavaType.IsNullable {
+			return fmt.Sprintf("java.util.Optional.ofNullable(row.getValue(%d)).map(%s::fromDatabase).orElse(null)", idx, ret.JavaType.Converter)
+		}
+		return fmt.Sprintf("%s.fromDatabase(row.getValue(%d))", ret.JavaType.Converter, idx)
+	}
+
+	if ret.JavaType.IsList && ret.JavaType.IsEnum {
+		// enum arrays are read as the string values of their elements
+		mapped := ".map(" + typeName + "::fromValue).toList()"
+		if ret.JavaType.IsNullable {
+			return fmt.Sprintf("java.util.Optional.ofNullable(row.get(String[].class, %d)).map(values -> java.util.Arrays.stream(values)%s).orElse(null)", idx, mapped)
+		}
+		return fmt.Sprintf("java.util.Arrays.stream(row.get(String[].class, %d))%s", idx, mapped)
+	}
+
+	if ret.JavaType.IsList {
+		// arrays are only supported by postgres, and are read as java arrays
+		if ret.JavaType.IsNullable {
+			return fmt.Sprintf("java.util.Optional.ofNullable(row.get(%s[].class, %d)).map(java.util.Arrays::asList).orElse(null)", typeName, idx)
+		}
+		return fmt.Sprintf("java.util.Arrays.asList(row.get(%s[].class, %d))", typeName, idx)
+	}
+
+	if ret.JavaType.IsEnum {
+		// both postgres and mysql enums are read as their string value
+		if ret.JavaType.IsNullable {
+			return fmt.Sprintf("java.util.Optional.ofNullable(row.getString(%d)).map(%s::fromValue).orElse(null)", idx, typeName)
+		}
+		return fmt.Sprintf("%s.fromValue(row.getString(%d))", typeName, idx)
+	}
+
+	if ret.JavaType.Type == "byte[]" {
+		if ret.JavaType.IsNullable {
+			return fmt.Sprintf("java.util.Optional.ofNullable(row.getBuffer(%d)).map(io.vertx.core.buffer.Buffer::getBytes).orElse(null)", idx)
+		}
+		return fmt.Sprintf("row.getBuffer(%d).getBytes()", idx)
+	}
+
+	if slices.Contains(vertxGetterTypes, typeOnly) {
+		return fmt.Sprintf("row.get%s(%d)", typeOnly, idx)
+	}
+	return fmt.Sprintf("row.get(%s.class, %d)", typeName, idx)
+}
+
+func (vertxColumns) isNull(number int) string {
+	return fmt.Sprintf("row.getValue(%d) == null", number-1)
+}
+
+// vertxTupleValue generates the expression used to add the given value expression to a Tuple. Null values can be
+// added directly.
+func vertxTupleValue(arg core.QueryArg, value string, t *importTracker) string {
+	bound := value
+	switch {
+	case arg.JavaType.Converter != "":
+		bound = arg.JavaType.Converter + ".toDatabase(" + value + ")"
+	case arg.JavaType.IsList && arg.JavaType.IsEnum:
+		bound = value + ".stream().map(" + t.Type(arg.JavaType.Type) + "::getValue).toArray(String[]::new)"
+	case arg.JavaType.IsList:
+		bound = value + ".toArray(new " + t.Type(arg.JavaType.Type) + "[0])"
+	case arg.JavaType.IsEnum:
+		// enums are bound using their database value, which both postgres and mysql accept for enum parameters
+		bound = value + ".getValue()"
+	case arg.JavaType.Type == "byte[]":
+		bound = "io.vertx.core.buffer.Buffer.buffer(" + value + ")"
+	}
+
+	if arg.JavaType.IsNullable && bound != value {
+		return value + " == null ? null : " + bound
+	}
+	return bound
+}
+
+// writeVertxTuple writes the construction of the Tuple containing the arguments of the given query, returning the
+// expression referring to it. If the query contains any sqlc.slice arguments the tuple is built incrementally, as the
+// number of values is only known at runtime.
+func writeVertxTuple(sb *IndentStringBuilder, config core.Config, q core.Query, level int, t *importTracker) string {
+	tuple := t.Type("io.vertx.sqlclient.Tuple")
+	if len(q.Args) == 0 {
+		return tuple + ".tuple()"
+	}
+
+	if !hasSliceArgs(q) {
+		values := make([]string, 0, len(q.Args))
+		for _, arg := range q.Args {
+			values = append(values, vertxTupleValue(arg, argValue(config, q, arg), t))
+		}
+		return tuple + ".of(" + strings.Join(values, ", ") + ")"
+	}
+
+	sb.WriteIndentedString(level, "var tuple = "+tuple+".tuple();\n")
+	for _, arg := range q.Args {
+		if !arg.IsSlice {
+			sb.WriteIndentedString(level, "tuple.addValue("+vertxTupleValue(arg, argValue(config, q, arg), t)+");\n")
+			continue
+		}
+
+		elem := arg
+		elem.JavaType.IsList = false
+		elem.JavaType.IsNullable = false
+
+		sb.WriteIndentedString(level, "for (var elem : "+argValue(config, q, arg)+") {\n")
+		writeSliceElementCheck(sb, arg, level+1)
+		sb.WriteIndentedString(level+1, "tuple.addValue("+vertxTupleValue(elem, "elem", t)+");\n")
+		sb.WriteIndentedString(level, "}\n")
+	}
+	return "tuple"
+}
+
+// vertxReturnType resolves the return type of the method generated for the given query using the vertx backend,
+// which is the future of the type returned by the JDBC backend.
+func vertxReturnType(q core.Query, t *importTracker) string {
+	return t.Type("io.vertx.core.Future") + "<" + boxedReturnType(q, t) + ">"
+}
+
+// writeVertxPreparedQuery writes the creation of the prepared query for the given query, mapping each row returned
+// using the same expressions as the other backends if the method returns the rows.
+func writeVertxPreparedQuery(sb *IndentStringBuilder, queryText string, q core.Query, embeddedModels core.EmbeddedModels, t *importTracker) {
+	switch q.Command {
+	case core.One, core.Many, core.BatchOne, core.BatchMany:
+	default:
+		sb.WriteIndentedString(2, "return client.preparedQuery("+queryText+")")
+		return
+	}
+
+	sb.WriteIndentedString(2, "return client.preparedQuery("+queryText+").mapping(row -> {\n")
+	createResultRecord(sb, vertxColumns{}, 3, q, embeddedModels, t)
+	sb.WriteIndentedString(3, "return ret;\n")
+	sb.WriteIndentedString(2, "})")
+}
+
+// writeSingleResult writes the conversion of the rows in the RowSet named "result", which must contain at most one
+// row, into an Optional, which is consumed by the given format string.
+func writeSingleResult(sb *IndentStringBuilder, level int, consume string, q core.Query, t *importTracker) {
+	sb.WriteIndentedString(level, "if (result.size() > 1) {\n")
+	sb.WriteIndentedString(level+1, "throw new IllegalStateException(\"expected one row in result set, but got many\");\n")
+	sb.WriteIndentedString(level, "}\n")
+	sb.WriteIndentedString(level, fmt.Sprintf(consume, "result.size() == 0 ? "+t.Type("java.util.Optional")+".empty() : "+optionalOf(q, t)+"(result.iterator().next())")+"\n")
+}
+
+// writeVertxMethodBody writes the body of the method generated for the given query using the vertx backend. The
+// query is executed when the method is called, and each row is mapped as the results are received.
+func writeVertxMethodBody(sb *IndentStringBuilder, config core.Config, engine string, q core.Query, embeddedModels core.EmbeddedModels, t *importTracker) {
+	if q.Command.TakesParamsList() {
+		// each element of the params is executed as part of a single batch
+		sb.WriteIndentedString(2, "var batch = new "+t.Type("java.util.ArrayList")+"<"+t.Type("io.vertx.sqlclient.Tuple")+">();\n")
+		sb.WriteIndentedString(2, "for (var row : params) {\n")
+		sb.WriteIndentedString(3, "batch.add("+writeVertxTuple(sb, config, q, 3, t)+");\n")
+		sb.WriteIndentedString(2, "}\n")
+		sb.WriteIndentedString(2, "if (batch.isEmpty()) {\n")
+		sb.WriteIndentedString(3, "// a batch cannot be executed without any tuples\n")
+		sb.WriteIndentedString(3, "return "+t.Type("io.vertx.core.Future")+".succeededFuture("+emptyBatchResult(q, t)+");\n")
+		sb.WriteIndentedString(2, "}\n\n")
+
+		writeVertxPreparedQuery(sb, q.MethodName, q, embeddedModels, t)
+		sb.WriteString(".executeBatch(batch).map(rows -> {\n")
+
+		switch q.Command {
+		case core.CopyFrom:
+			sb.WriteIndentedString(3, "var inserted = 0L;\n")
+			sb.WriteIndentedString(3, "for (var result = rows; result != null; result = result.next()) {\n")
+			sb.WriteIndentedString(4, "inserted += result.rowCount();\n")
+			sb.WriteIndentedString(3, "}\n")
+			sb.WriteIndentedString(3, "return inserted;\n")
+		case core.BatchExec:
+			sb.WriteIndentedString(3, "var counts = new int[batch.size()];\n")
+			sb.WriteIndentedString(3, "var result = rows;\n")
+			sb.WriteIndentedString(3, "for (var i = 0; i < counts.length; i++) {\n")
+			sb.WriteIndentedString(4, "counts[i] = result.rowCount();\n")
+			sb.WriteIndentedString(4, "result = result.next();\n")
+			sb.WriteIndentedString(3, "}\n")
+			sb.WriteIndentedString(3, "return counts;\n")
+		case core.BatchOne:
+			sb.WriteIndentedString(3, "var retList = new "+t.Type("java.util.ArrayList")+"<"+t.Type("java.util.Optional")+"<"+rowType(q, t)+">>(batch.size());\n")
+			sb.WriteIndentedString(3, "for (var result = rows; result != null; result = result.next()) {\n")
+			writeSingleResult(sb, 4, "retList.add(%s);", q, t)
+			sb.WriteIndentedString(3, "}\n")
+			sb.WriteIndentedString(3, "return retList;\n")
+		case core.BatchMany:
+			sb.WriteIndentedString(3, "var retList = new "+t.Type("java.util.ArrayList")+"<"+t.Type("java.util.List")+"<"+rowType(q, t)+">>(batch.size());\n")
+			sb.WriteIndentedString(3, "for (var result = rows; result != null; result = result.next()) {\n")
+			sb.WriteIndentedString(4, "retList.add(rowList(result));\n")
+			sb.WriteIndentedString(3, "}\n")
+			sb.WriteIndentedString(3, "return retList;\n")
+		}
+		sb.WriteIndentedString(2, "});\n")
+		return
+	}
+
+	queryText := expandSlices(sb, config, q)
+	tuple := writeVertxTuple(sb, config, q, 2, t)
+	writeVertxPreparedQuery(sb, queryText, q, embeddedModels, t)
+	sb.WriteString(".execute(" + tuple + ")")
+
+	switch q.Command {
+	case core.One:
+		sb.WriteString(".map(result -> {\n")
+		writeSingleResult(sb, 3, "return %s;", q, t)
+		sb.WriteIndentedString(2, "});\n")
+	case core.Many:
+		sb.WriteString(".map(rows -> rowList(rows));\n")
+	case core.Exec:
+		sb.WriteString(".mapEmpty();\n")
+	case core.ExecRows:
+		sb.WriteString(".map(rows -> rows.rowCount());\n")
+	case core.ExecResult:
+		if engine == "mysql" {
+			sb.WriteString(".map(rows -> rows.property(io.vertx.mysqlclient.MySQLClient.LAST_INSERTED_ID));\n")
+			return
+		}
+
+		// postgres does not report generated keys, they must be returned by the query instead
+		sb.WriteString(".map(rows -> {\n")
+		sb.WriteIndentedString(3, "var iterator = rows.iterator();\n")
+		sb.WriteIndentedString(3, "if (!iterator.hasNext()) {\n")
+		sb.WriteIndentedString(4, "throw new "+t.Type("java.util.NoSuchElementException")+"(\"no generated key returned\");\n")
+		sb.WriteIndentedString(3, "}\n")
+		sb.WriteIndentedString(3, "return iterator.next().getLong(0);\n")
+		sb.WriteIndentedString(2, "});\n")
+	}
+}
+
+// emptyBatchResult returns the expression for the result of a batch or :copyfrom query given no params.
+func emptyBatchResult(q core.Query, t *importTracker) string {
+	switch q.Command {
+	case core.CopyFrom:
+		return "0L"
+	case core.BatchExec:
+		return "new int[0]"
+	default:
+		return t.Type("java.util.List") + ".of()"
+	}
+}
+
+// BuildVertxQueriesFile builds the queries class for the given query file using the vertx backend. The class is
+// constructed using any Vert.x SqlClient - a pool, or a connection which may be part of a transaction.
+func BuildVertxQueriesFile(engine string, config core.Config, queryFilename string, queries []core.Query, embeddedModels core.EmbeddedModels, nullableHelpers core.NullableHelpers) (string, []byte, error) {
+	className := QueriesClassName(queryFilename)
+
+	t := newImportTracker(config.Package, queriesPackageTypes(queryFilename, queries)...)
+	t.reserve(queriesJdkTypes...)
+	t.reserve("io.vertx.core.Future", "io.vertx.sqlclient.SqlClient", "io.vertx.sqlclient.Tuple", "io.vertx.sqlclient.RowSet")
+	nonNullAnnotation := t.Annotation(config.NonNullAnnotation)
+	nullableAnnotation := t.Annotation(config.NullableAnnotation)
+
+	header, body, methodModifiers := newQueriesClass(config, queryFilename, t)
+
+	sqlClient := t.Type("io.vertx.sqlclient.SqlClient")
+
+	body.WriteIndentedString(1, "private final "+sqlClient+" client;\n\n")
+	body.WriteIndentedString(1, "public "+className+"("+sqlClient+" client) {\n")
+	body.WriteIndentedString(2, "this.client = client;\n")
+	body.WriteIndentedString(1, "}\n")
+
+	if config.ExposeConnection {
+		body.WriteString("\n")
+		body.WriteIndentedString(1, "public "+sqlClient+" getClient() {return this.client;}\n")
+	}
+
+	if slices.ContainsFunc(queries, func(q core.Query) bool { return q.Command == core.Many || q.Command == core.BatchMany }) {
+		rowSet := t.Type("io.vertx.sqlclient.RowSet")
+		body.WriteString("\n")
+		body.WriteIndentedString(1, "private static <T> "+t.Type("java.util.List")+"<T> rowList("+core.Annotate(rowSet+"<T>", nonNullAnnotation)+" rows) {\n")
+		body.WriteIndentedString(2, "var retList = new "+t.Type("java.util.ArrayList")+"<T>(rows.size());\n")
+		body.WriteIndentedString(2, "rows.forEach(retList::add);\n")
+		body.WriteIndentedString(2, "return retList;\n")
+		body.WriteIndentedString(1, "}\n")
+	}
+
+	for _, q := range queries {
+		body.WriteString("\n")
+		body.writeQueryText(config, q)
+		if !config.EmitInterface {
+			body.writeQueryRecords(config, q, t, nonNullAnnotation, nullableAnnotation)
+		}
+
+		body.WriteString("\n")
+		body.writeMethodSignature(methodModifiers, vertxReturnType(q, t), q.MethodName, q, "", t, nonNullAnnotation, nullableAnnotation)
+		body.WriteString(" {\n")
+		writeVertxMethodBody(body, config, engine, q, embeddedModels, t)
+		body.WriteIndentedString(1, "}\n")
+	}
+	return finishQueriesClass(className, header, body, t)
+}
@@ -0,0 +1,94 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tandemdude/sqlc-gen-java/internal/core"
+)
+
+func TestVertxBackend(t *testing.T) {
+	conf := testConfig
+	conf.Backend = core.BackendVertx
+	queries := []core.Query{
+		testQuery(core.One, ":one"),
+		testQuery(core.ExecRows, ":execrows"),
+	}
+	queries[0].Text = "SELECT id FROM foo WHERE id = $1"
+	queries[1].MethodName = "bar"
+
+	_, contents, err := BuildVertxQueriesFile("postgresql", conf, "queries.sql", queries, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(contents)
+	assertInOrder(t, out, []string{
+		"    public Queries(SqlClient client) {\n        this.client = client;\n    }\n",
+		"        SELECT id FROM foo WHERE id = $1\n",
+		"    public Future<Optional<Integer>> foo(\n        int id\n    ) {\n",
+		"        return client.preparedQuery(foo).mapping(row -> {\n            var ret = row.getInteger(0);\n",
+		"        }).execute(Tuple.of(id)).map(result -> {\n",
+		"            return result.size() == 0 ? Optional.empty() : Optional.of(result.iterator().next());\n",
+		"    public Future<Integer> bar(\n        int id\n    ) {\n",
+		"        return client.preparedQuery(bar).execute(Tuple.of(id)).map(rows -> rows.rowCount());\n",
+	})
+	if strings.Contains(out, "SQLException") || strings.Contains(out, "java.sql") {
+		t.Errorf("expected no JDBC types in output:\n%s", out)
+	}
+}
+
+func TestVertxEnumsAndArrays(t *testing.T) {
+	conf := testConfig
+	conf.Backend = core.BackendVertx
+	q := testQuery(core.Many, ":many")
+	q.Args = []core.QueryArg{
+		{Number: 1, Name: "statuses", JavaType: core.JavaType{SqlType: "status", Type: "Status", IsList: true, IsEnum: true}},
+		{Number: 2, Name: "data", JavaType: core.JavaType{SqlType: "bytea", Type: "byte[]", IsNullable: true}},
+	}
+	q.Returns = []core.QueryReturn{
+		{Name: "status", JavaType: core.JavaType{SqlType: "status", Type: "Status", IsEnum: true}},
+		{Name: "tags", JavaType: core.JavaType{SqlType: "text", Type: "String", IsList: true, IsNullable: true}},
+		{Name: "data", JavaType: core.JavaType{SqlType: "bytea", Type: "byte[]"}},
+		{Name: "history", JavaType: core.JavaType{SqlType: "status", Type: "Status", IsList: true, IsEnum: true, IsNullable: true}},
+	}
+
+	_, contents, err := BuildVertxQueriesFile("postgresql", conf, "queries.sql", []core.Query{q}, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertInOrder(t, string(contents), []string{
+		"                Status.fromValue(row.getString(0)),\n",
+		"                java.util.Optional.ofNullable(row.get(String[].class, 1)).map(java.util.Arrays::asList).orElse(null),\n",
+		"                row.getBuffer(2).getBytes(),\n",
+		"                java.util.Optional.ofNullable(row.get(String[].class, 3)).map(values -> java.util.Arrays.stream(values).map(Status::fromValue).toList()).orElse(null)\n",
+		"        }).execute(Tuple.of(statuses.stream().map(Status::getValue).toArray(String[]::new), data == null ? null : io.vertx.core.buffer.Buffer.buffer(data))).map(rows -> rowList(rows));\n",
+	})
+}
+
+func TestVertxBatchCommands(t *testing.T) {
+	conf := testConfig
+	conf.Backend = core.BackendVertx
+	queries := []core.Query{
+		testQuery(core.BatchExec, ":batchexec"),
+		testQuery(core.CopyFrom, ":copyfrom"),
+	}
+	queries[1].MethodName = "bar"
+
+	_, contents, err := BuildVertxQueriesFile("mysql", conf, "queries.sql", queries, core.EmbeddedModels{}, core.NullableHelpers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertInOrder(t, string(contents), []string{
+		"    public Future<int[]> foo(\n",
+		"        for (var row : params) {\n            batch.add(Tuple.of(row.id()));\n        }\n",
+		"            return Future.succeededFuture(new int[0]);\n",
+		"        return client.preparedQuery(foo).executeBatch(batch).map(rows -> {\n",
+		"                counts[i] = result.rowCount();\n                result = result.next();\n",
+		"    public Future<Long> bar(\n",
+		"            return Future.succeededFuture(0L);\n",
+		"                inserted += result.rowCount();\n",
+	})
+}
@@ -1,14 +1,90 @@
 package core
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	BackendJdbc       = "jdbc"
+	BackendSpringJdbc = "spring-jdbc"
+	BackendR2dbc      = "r2dbc"
+	BackendVertx      = "vertx"
+
+	ModelStyleRecord = "record"
+	ModelStyleClass  = "class"
+)
+
 type Config struct {
 	Package                     string   `json:"package"`
 	EmitExactTableNames         bool     `json:"emit_exact_table_names"`
 	InflectionExcludeTableNames []string `json:"inflection_exclude_table_names"`
-	// TODO - implement support for this
-	QueryParameterLimit int    `json:"query_parameter_limit"`
+	// QueryParameterLimit is the maximum number of method parameters before a params record is generated instead.
+	// Nil means no limit.
+	QueryParameterLimit *int   `json:"query_parameter_limit"`
 	IndentChar          string `json:"indent_char"`
 	CharsPerIndentLevel int    `json:"chars_per_indent_level"`
 	NullableAnnotation  string `json:"nullable_annotation"`
 	NonNullAnnotation   string `json:"non_null_annotation"`
 	ExposeConnection    bool   `json:"expose_connection"`
+	EmitInterface       bool   `json:"emit_interface"`
+	// EmitDataSourceConstructor is whether each queries class can also be constructed using a javax.sql.DataSource,
+	// in which case every method borrows a connection from the data source for the duration of the call.
+	EmitDataSourceConstructor bool `json:"emit_datasource_constructor"`
+	// EmitAllModels is whether a model record is generated for every table, instead of only for embedded tables.
+	EmitAllModels bool `json:"emit_all_models"`
+	// EmitStreams is whether a streaming variant is generated for every :many query, instead of only for those
+	// annotated with "@stream".
+	EmitStreams     bool `json:"emit_streams"`
+	StreamFetchSize int  `json:"stream_fetch_size"`
+
+	// EmitAsync is whether an asynchronous variant of every query method is generated, returning a CompletableFuture
+	// completed using a caller supplied Executor.
+	EmitAsync bool `json:"emit_async"`
+
+	// ModelStyle is how the models and the records nested in each queries class are generated, either "record", or
+	// "class" for plain final classes compatible with Java versions without record support.
+	ModelStyle string `json:"model_style"`
+	// EmitBuilders is whether a builder, and copy methods replacing a single value, are generated for each model and
+	// each query's result record.
+	EmitBuilders bool `json:"emit_builders"`
+
+	// Backend is the database access API used by the generated queries classes, one of "jdbc", "spring-jdbc",
+	// "r2dbc" or "vertx".
+	Backend string `json:"backend"`
+
+	Overrides []Override `json:"overrides"`
+}
+
+// IsReactiveBackend returns whether the configured backend generates non-blocking methods, which execute queries
+// using the engine's native bind markers.
+func (c Config) IsReactiveBackend() bool {
+	return c.Backend == BackendR2dbc || c.Backend == BackendVertx
+}
+
+// Override replaces the default java type mapping for either a database type, or a specific column.
+type Override struct {
+	// DbType is the database type to override, e.g. "jsonb" or "pg_catalog.int4".
+	DbType string `json:"db_type"`
+	// Column is the table qualified - optionally also schema qualified - column to override, e.g. "users.email".
+	Column string `json:"column"`
+	// JavaType is the fully qualified java type to use instead of the default mapping.
+	JavaType string `json:"java_type"`
+	// Converter is the fully qualified name of a class with static "fromDatabase(Object)" and "toDatabase(JavaType)"
+	// methods, used to convert values when reading from and writing to the database. Optional.
+	Converter string `json:"converter"`
+}
+
+func (o Override) Validate() error {
+	if o.JavaType == "" {
+		return errors.New("override must specify java_type")
+	}
+	if (o.DbType == "") == (o.Column == "") {
+		return fmt.Errorf("override for %s must specify exactly one of db_type or column", o.JavaType)
+	}
+	if o.Column != "" && !strings.Contains(o.Column, ".") {
+		return fmt.Errorf("override column %q must be qualified with the table name", o.Column)
+	}
+	return nil
 }
@@ -3,6 +3,7 @@ package core
 import (
 	"fmt"
 	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -15,6 +16,9 @@ const (
 	ExecRows
 	ExecResult
 	CopyFrom
+	BatchExec
+	BatchOne
+	BatchMany
 )
 
 func QueryCommandFor(rawCommand string) (QueryCommand, error) {
@@ -31,23 +35,46 @@ func QueryCommandFor(rawCommand string) (QueryCommand, error) {
 		return ExecResult, nil
 	case ":copyfrom":
 		return CopyFrom, nil
+	case ":batchexec":
+		return BatchExec, nil
+	case ":batchone":
+		return BatchOne, nil
+	case ":batchmany":
+		return BatchMany, nil
 	default:
 		return One, fmt.Errorf(`unknown query command "%s"`, rawCommand)
 	}
 }
 
+// IsBatch returns whether the command executes the query once for each element of a list of parameters.
+func (c QueryCommand) IsBatch() bool {
+	return c == BatchExec || c == BatchOne || c == BatchMany
+}
+
+// TakesParamsList returns whether the generated method accepts a collection of params records instead of individual
+// arguments.
+func (c QueryCommand) TakesParamsList() bool {
+	return c == CopyFrom || c.IsBatch()
+}
+
 type JavaType struct {
 	SqlType    string
 	Type       string
 	IsList     bool
 	IsNullable bool
 	IsEnum     bool
+	// Converter is the fully qualified name of the class used to convert values of this type, if one was configured.
+	Converter string
 }
 
 type QueryArg struct {
 	Number   int
 	Name     string
+	Column   string
 	JavaType JavaType
+	// IsSlice is whether this argument was declared using sqlc.slice, and so must be expanded into one placeholder
+	// per element at runtime.
+	IsSlice bool
 }
 
 // TODO - enum types
@@ -69,14 +96,43 @@ var typeToJavaSqlTypeConst = map[string]string{
 	"Double":  "DOUBLE",
 }
 
+// the sqlite driver has no native date/time storage, values are bound as ISO-8601 text and read back using
+// a helper method which understands both the text and epoch millisecond representations
+var sqliteTemporalTypes = []string{"java.time.LocalDate", "java.time.LocalTime", "java.time.LocalDateTime"}
+
+// IsSqliteTemporal returns whether the given java type requires special handling for the sqlite engine.
+func IsSqliteTemporal(engine, typ string) bool {
+	return engine == "sqlite" && slices.Contains(sqliteTemporalTypes, typ)
+}
+
+// JavaSqlType returns the name of the java.sql.Types constant used to bind null values of this type, if the type is
+// bound using a dedicated setter which cannot accept null.
+func (t JavaType) JavaSqlType() (string, bool) {
+	javaSqlType, ok := typeToJavaSqlTypeConst[t.Type[strings.LastIndex(t.Type, ".")+1:]]
+	return javaSqlType, ok
+}
+
 func (q QueryArg) BindStmt(engine string) string {
+	return q.BindStmtAt(engine, strconv.Itoa(q.Number), q.Name)
+}
+
+// BindStmtAt generates the bind statement for the given value expression, binding it to the parameter index given
+// by the index expression.
+func (q QueryArg) BindStmtAt(engine, index, value string) string {
 	typeOnly := q.JavaType.Type[strings.LastIndex(q.JavaType.Type, ".")+1:]
 
+	if q.JavaType.Converter != "" {
+		if q.JavaType.IsNullable {
+			return fmt.Sprintf("stmt.setObject(%s, %s == null ? null : %s.toDatabase(%s));", index, value, q.JavaType.Converter, value)
+		}
+		return fmt.Sprintf("stmt.setObject(%s, %s.toDatabase(%s));", index, q.JavaType.Converter, value)
+	}
+
 	if q.JavaType.IsList {
 		if q.JavaType.IsNullable {
-			return fmt.Sprintf("stmt.setArray(%d, %s == null ? null : conn.createArrayOf(\"%s\", %s.toArray()));", q.Number, q.Name, q.JavaType.SqlType, q.Name)
+			return fmt.Sprintf("stmt.setArray(%s, %s == null ? null : conn.createArrayOf(\"%s\", %s.toArray()));", index, value, q.JavaType.SqlType, value)
 		}
-		return fmt.Sprintf("stmt.setArray(%d, conn.createArrayOf(\"%s\", %s.toArray()));", q.Number, q.JavaType.SqlType, q.Name)
+		return fmt.Sprintf("stmt.setArray(%s, conn.createArrayOf(\"%s\", %s.toArray()));", index, q.JavaType.SqlType, value)
 	}
 
 	if slices.Contains(literalBindTypes, typeOnly) {
@@ -85,7 +141,7 @@ func (q QueryArg) BindStmt(engine string) string {
 		if found, ok := typeToMethodRename[typeOnly]; ok {
 			typeOnly = found
 		}
-		rawSet := fmt.Sprintf("stmt.set%s(%d, %s);", typeOnly, q.Number, q.Name)
+		rawSet := fmt.Sprintf("stmt.set%s(%s, %s);", typeOnly, index, value)
 
 		// if the arg is not nullable, or supports null directly though the method
 		if !q.JavaType.IsNullable || !ok {
@@ -96,27 +152,53 @@ func (q QueryArg) BindStmt(engine string) string {
 		if (%s != null) {
 		    %s
 		} else {
-		    stmt.setNull(%d, java.sql.Types.%s);
+		    stmt.setNull(%s, java.sql.Types.%s);
+		}
+		`, value, rawSet, index, javaSqlType)
+	}
+
+	if IsSqliteTemporal(engine, q.JavaType.Type) {
+		if q.JavaType.IsNullable {
+			return fmt.Sprintf("stmt.setString(%s, %s == null ? null : %s.toString());", index, value, value)
 		}
-		`, q.Name, rawSet, q.Number, javaSqlType)
+		return fmt.Sprintf("stmt.setString(%s, %s.toString());", index, value)
 	}
 
 	if q.JavaType.IsEnum {
 		// postgres doesn't like it if you setString an enum directly unfortunately
 		if engine == "postgresql" {
 			if q.JavaType.IsNullable {
-				return fmt.Sprintf("stmt.setObject(%d, %s == null ? null : %s.getValue(), java.sql.Types.OTHER);", q.Number, q.Name, q.Name)
+				return fmt.Sprintf("stmt.setObject(%s, %s == null ? null : %s.getValue(), java.sql.Types.OTHER);", index, value, value)
 			}
-			return fmt.Sprintf("stmt.setObject(%d, %s.getValue(), java.sql.Types.OTHER);", q.Number, q.Name)
+			return fmt.Sprintf("stmt.setObject(%s, %s.getValue(), java.sql.Types.OTHER);", index, value)
 		}
 
 		if q.JavaType.IsNullable {
-			return fmt.Sprintf("stmt.setString(%d, %s == null ? null : %s.getValue());", q.Number, q.Name, q.Name)
+			return fmt.Sprintf("stmt.setString(%s, %s == null ? null : %s.getValue());", index, value, value)
 		}
-		return fmt.Sprintf("stmt.setString(%d, %s.getValue());", q.Number, q.Name)
+		return fmt.Sprintf("stmt.setString(%s, %s.getValue());", index, value)
 	}
 
-	return fmt.Sprintf("stmt.setObject(%d, %s);", q.Number, q.Name)
+	return fmt.Sprintf("stmt.setObject(%s, %s);", index, value)
+}
+
+// CopyValue generates the expression used to write this argument as a field of a postgres COPY FROM STDIN stream,
+// given the expression reading the value from the params record.
+func (q QueryArg) CopyValue(value string) string {
+	if q.JavaType.Converter != "" {
+		if q.JavaType.IsNullable {
+			return fmt.Sprintf("%s == null ? null : %s.toDatabase(%s)", value, q.JavaType.Converter, value)
+		}
+		return fmt.Sprintf("%s.toDatabase(%s)", q.JavaType.Converter, value)
+	}
+
+	if q.JavaType.IsEnum && !q.JavaType.IsList {
+		if q.JavaType.IsNullable {
+			return fmt.Sprintf("%s == null ? null : %s.getValue()", value, value)
+		}
+		return value + ".getValue()"
+	}
+	return value
 }
 
 type QueryReturn struct {
@@ -125,14 +207,23 @@ type QueryReturn struct {
 	EmbeddedModel *string
 }
 
-func (q QueryReturn) ResultStmt(number int) string {
+// ResultStmt generates the expression used to read this return value from the result set column with the given
+// number. The type name is the name the return type is referred to by within the generated file.
+func (q QueryReturn) ResultStmt(engine string, number int, typeName string) string {
 	typeOnly := q.JavaType.Type[strings.LastIndex(q.JavaType.Type, ".")+1:]
 
+	if q.JavaType.Converter != "" {
+		if q.JavaType.IsNullable {
+			return fmt.Sprintf("java.util.Optional.ofNullable(results.getObject(%d)).map(%s::fromDatabase).orElse(null)", number, q.JavaType.Converter)
+		}
+		return fmt.Sprintf("%s.fromDatabase(results.getObject(%d))", q.JavaType.Converter, number)
+	}
+
 	if q.JavaType.IsList {
 		if q.JavaType.IsNullable {
-			return fmt.Sprintf("getList(results, %d, %s[].class)", number, typeOnly)
+			return fmt.Sprintf("getList(results, %d, %s[].class)", number, typeName)
 		}
-		return fmt.Sprintf("Arrays.asList(%s[].class.cast(results.getArray(%d).getArray()))", typeOnly, number)
+		return fmt.Sprintf("java.util.Arrays.asList(%s[].class.cast(results.getArray(%d).getArray()))", typeName, number)
 	}
 
 	if slices.Contains(literalBindTypes, typeOnly) {
@@ -148,14 +239,22 @@ func (q QueryReturn) ResultStmt(number int) string {
 		return fmt.Sprintf("results.get%s(%d)", typeOnly, number)
 	}
 
+	if IsSqliteTemporal(engine, q.JavaType.Type) {
+		return fmt.Sprintf("get%s(results, %d)", typeOnly, number)
+	}
+
 	if q.JavaType.IsEnum {
 		if q.JavaType.IsNullable {
-			return fmt.Sprintf("Optional.ofNullable(results.getString(%d)).map(%s::fromValue).orElse(null)", number, typeOnly)
+			return fmt.Sprintf("java.util.Optional.ofNullable(results.getString(%d)).map(%s::fromValue).orElse(null)", number, typeName)
 		}
-		return fmt.Sprintf("%s.fromValue(results.getString(%d))", typeOnly, number)
+		return fmt.Sprintf("%s.fromValue(results.getString(%d))", typeName, number)
+	}
+
+	if q.JavaType.Type == "Object" {
+		return fmt.Sprintf("results.getObject(%d)", number)
 	}
 
-	return fmt.Sprintf("results.getObject(%d, %s.class)", number, typeOnly)
+	return fmt.Sprintf("results.getObject(%d, %s.class)", number, typeName)
 }
 
 type Query struct {
@@ -166,6 +265,18 @@ type Query struct {
 	MethodName   string
 	Args         []QueryArg
 	Returns      []QueryReturn
+	// InsertIntoTable is the (optionally schema qualified) table name targeted by :copyfrom queries.
+	InsertIntoTable string
+	// Placeholders is the parameter number bound to each JDBC placeholder, in order. Nil if each placeholder binds
+	// the parameter with the same number.
+	Placeholders []int
+	// Stream is whether a variant of a :many query returning a lazily populated stream should also be generated.
+	Stream bool
+	// BatchReturning is whether the rows of a :batchone or :batchmany query are read from the generated keys of a
+	// single JDBC batch, which requires pgjdbc and a RETURNING clause. Otherwise each element is executed separately.
+	BatchReturning bool
+	// UseParamsRecord is whether the arguments are passed to the method using a params record instead of individually.
+	UseParamsRecord bool
 }
 
 type NullableHelpers struct {
@@ -175,6 +286,10 @@ type NullableHelpers struct {
 	Double  bool
 	Boolean bool
 	List    bool
+	// sqlite specific temporal parsing helpers
+	LocalDate     bool
+	LocalTime     bool
+	LocalDateTime bool
 }
 
 type Enum struct {
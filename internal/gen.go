@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"regexp"
 	"slices"
-	"strconv"
 	"strings"
 
 	"github.com/iancoleman/strcase"
@@ -17,12 +16,18 @@ import (
 	"github.com/tandemdude/sqlc-gen-java/internal/core"
 	"github.com/tandemdude/sqlc-gen-java/internal/inflection"
 	"github.com/tandemdude/sqlc-gen-java/internal/sqltypes"
+	"google.golang.org/protobuf/proto"
 )
 
 var (
 	defaultIndentChar          = " "
 	defaultCharsPerIndentLevel = 4
-	postgresPlaceholderRegexp  = regexp.MustCompile(`\B\$\d+\b`)
+	defaultStreamFetchSize     = 1000
+	streamAnnotation           = "@stream"
+	nullableEmbedAnnotation    = "@nullable_embed"
+	outerJoinRegexp            = regexp.MustCompile(`(?i)\b(LEFT|RIGHT|FULL)(?:\s+OUTER)?\s+JOIN\s+(?:"?\w+"?\.)?"?(\w+)"?`)
+	tableReferenceRegexp       = regexp.MustCompile(`(?i)\b(?:FROM|JOIN)\s+(?:"?\w+"?\.)?"?(\w+)"?`)
+	returningRegexp            = regexp.MustCompile(`(?i)\bRETURNING\b`)
 )
 
 type JavaGenerator struct {
@@ -45,6 +50,9 @@ func NewJavaGenerator(req *plugin.GenerateRequest) (*JavaGenerator, error) {
 		CharsPerIndentLevel: defaultCharsPerIndentLevel,
 		NullableAnnotation:  "org.jspecify.annotations.Nullable",
 		NonNullAnnotation:   "org.jspecify.annotations.NonNull",
+		StreamFetchSize:     defaultStreamFetchSize,
+		Backend:             core.BackendJdbc,
+		ModelStyle:          core.ModelStyleRecord,
 	}
 	if len(req.PluginOptions) > 0 {
 		if err := json.Unmarshal(req.PluginOptions, &conf); err != nil {
@@ -52,12 +60,48 @@ func NewJavaGenerator(req *plugin.GenerateRequest) (*JavaGenerator, error) {
 		}
 	}
 
+	if conf.QueryParameterLimit != nil && *conf.QueryParameterLimit < 0 {
+		return nil, errors.New("query_parameter_limit must not be negative")
+	}
+
+	switch conf.Backend {
+	case core.BackendJdbc:
+	case core.BackendSpringJdbc, core.BackendR2dbc, core.BackendVertx:
+		if conf.EmitDataSourceConstructor {
+			return nil, fmt.Errorf("emit_datasource_constructor is not supported by the %s backend", conf.Backend)
+		}
+		if conf.IsReactiveBackend() && conf.EmitAsync {
+			return nil, fmt.Errorf("emit_async is not supported by the %s backend, which is already asynchronous", conf.Backend)
+		}
+		if conf.IsReactiveBackend() && req.Settings.Engine == "sqlite" {
+			return nil, fmt.Errorf("the %s backend does not support the sqlite engine", conf.Backend)
+		}
+	default:
+		return nil, fmt.Errorf("backend %q is not supported", conf.Backend)
+	}
+
+	if conf.ModelStyle != core.ModelStyleRecord && conf.ModelStyle != core.ModelStyleClass {
+		return nil, fmt.Errorf("model_style %q is not supported", conf.ModelStyle)
+	}
+
+	if conf.StreamFetchSize <= 0 {
+		return nil, errors.New("stream_fetch_size must be positive")
+	}
+
+	for _, override := range conf.Overrides {
+		if err := override.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	var typeConversionFunc sqltypes.TypeConversionFunc
 	switch req.Settings.Engine {
 	case "postgresql":
 		typeConversionFunc = sqltypes.PostgresTypeToJavaType
 	case "mysql":
 		typeConversionFunc = sqltypes.MysqlTypeToJavaType
+	case "sqlite":
+		typeConversionFunc = sqltypes.SqliteTypeToJavaType
 	default:
 		return nil, fmt.Errorf("engine %q is not supported", req.Settings.Engine)
 	}
@@ -74,58 +118,146 @@ func NewJavaGenerator(req *plugin.GenerateRequest) (*JavaGenerator, error) {
 	}, nil
 }
 
-func (gen *JavaGenerator) fixQueryPlaceholders(query string) (string, error) {
-	if gen.req.Settings.Engine != "postgresql" {
-		return query, nil
+// fixQueryPlaceholders replaces the numbered postgres placeholders in the given query with JDBC "?" placeholders. The
+// parameter number referenced by each JDBC placeholder is returned in order, as a parameter may be referenced
+// multiple times, or out of order. For other engines, and for the reactive backends which support the numbered
+// placeholders natively, the query is returned unchanged, alongside a nil mapping.
+func (gen *JavaGenerator) fixQueryPlaceholders(query string) (string, []int, error) {
+	if gen.req.Settings.Engine != "postgresql" || gen.conf.IsReactiveBackend() {
+		return query, nil, nil
 	}
+	return rewritePostgresPlaceholders(query)
+}
 
-	var placeholders []string
-	newQuery := postgresPlaceholderRegexp.ReplaceAllStringFunc(query, func(s string) string {
-		placeholders = append(placeholders, s)
-		return "?"
-	})
-
-	for _, placeholder := range placeholders {
-		if _, err := strconv.Atoi(strings.TrimPrefix(placeholder, "$")); err != nil {
-			return "", fmt.Errorf("invalid placeholder in query: %s", placeholder)
+// annotationArgs returns the whitespace separated arguments following the given annotation in the query comments,
+// e.g. "-- @nullable_embed tokens". The boolean is false if no comment contains the annotation.
+func annotationArgs(comments []string, annotation string) ([]string, bool) {
+	var args []string
+	found := false
+	for _, comment := range comments {
+		fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(comment), "--"))
+		if len(fields) == 0 || fields[0] != annotation {
+			continue
 		}
+
+		found = true
+		args = append(args, fields[1:]...)
 	}
+	return args, found
+}
 
-	return newQuery, nil
+// hasAnnotation returns whether any of the given query comments starts with the given annotation, e.g. "-- @stream".
+func hasAnnotation(comments []string, annotation string) bool {
+	_, found := annotationArgs(comments, annotation)
+	return found
 }
 
-func (gen *JavaGenerator) parseQueryReturn(col *plugin.Column) (*core.QueryReturn, error) {
-	isEnum := false
-	strJavaType, err := gen.typeConversionFunc(col.Type)
-	if err != nil {
+// outerJoinedTables returns the names of the tables in the given query which may have no matching row due to an
+// outer join, meaning every column selected from them may be null. Tables preceding a right or full join are all
+// considered to be outer joined.
+func outerJoinedTables(query string) []string {
+	tables := make([]string, 0)
+	for _, match := range outerJoinRegexp.FindAllStringSubmatchIndex(query, -1) {
+		kind := strings.ToUpper(query[match[2]:match[3]])
+		if kind != "RIGHT" {
+			tables = append(tables, query[match[4]:match[5]])
+		}
+		if kind == "LEFT" {
+			continue
+		}
+
+		for _, ref := range tableReferenceRegexp.FindAllStringSubmatchIndex(query[:match[0]], -1) {
+			tables = append(tables, query[ref[2]:ref[3]])
+		}
+	}
+	return tables
+}
+
+// findOverride returns the configured override for the given column, if one exists. Column overrides take precedence
+// over database type overrides.
+func (gen *JavaGenerator) findOverride(col *plugin.Column) *core.Override {
+	var columnNames []string
+	if col.Table != nil && col.Table.Name != "" {
 		schema := col.Table.Schema
 		if schema == "" {
 			schema = gen.req.Catalog.DefaultSchema
 		}
 
-		enumQualifiedName := fmt.Sprintf("%s.%s", schema, col.Type.Name)
-		if _, ok := gen.enums[enumQualifiedName]; !ok {
-			return nil, err
+		name := col.Name
+		if col.OriginalName != "" {
+			name = col.OriginalName
 		}
+		columnNames = []string{col.Table.Name + "." + name, schema + "." + col.Table.Name + "." + name}
+	}
 
-		gen.usedEnums = append(gen.usedEnums, enumQualifiedName)
-		strJavaType = gen.conf.Package + ".enums." + codegen.EnumClassName(enumQualifiedName, gen.req.Catalog.DefaultSchema)
-		isEnum = true
+	for i, override := range gen.conf.Overrides {
+		if override.Column != "" && slices.Contains(columnNames, override.Column) {
+			return &gen.conf.Overrides[i]
+		}
+	}
+
+	dbType := sdk.DataType(col.Type)
+	for i, override := range gen.conf.Overrides {
+		if override.DbType != "" && (override.DbType == dbType || override.DbType == col.Type.Name) {
+			return &gen.conf.Overrides[i]
+		}
 	}
+	return nil
+}
 
+// resolveJavaType determines the java type used to represent the given column, taking into account any configured
+// overrides and enum types.
+func (gen *JavaGenerator) resolveJavaType(col *plugin.Column) (core.JavaType, error) {
 	if col.ArrayDims > 1 {
-		return nil, fmt.Errorf("multidimensional arrays are not supported, store JSON instead")
+		return core.JavaType{}, fmt.Errorf("multidimensional arrays are not supported, store JSON instead")
 	}
 
 	javaType := core.JavaType{
 		SqlType:    sdk.DataType(col.Type),
-		Type:       strJavaType,
 		IsList:     col.IsArray,
 		IsNullable: !col.NotNull,
-		IsEnum:     isEnum,
 	}
 
-	if javaType.IsNullable {
+	if override := gen.findOverride(col); override != nil {
+		if override.Converter != "" && col.IsArray {
+			return core.JavaType{}, fmt.Errorf("column %s: converters are not supported for array types", col.Name)
+		}
+
+		javaType.Type = override.JavaType
+		javaType.Converter = override.Converter
+		return javaType, nil
+	}
+
+	strJavaType, err := gen.typeConversionFunc(col.Type)
+	if err != nil {
+		// check if this is an enum type
+		schema := gen.req.Catalog.DefaultSchema
+		if col.Table != nil && col.Table.Schema != "" {
+			schema = col.Table.Schema
+		}
+
+		enumQualifiedName := fmt.Sprintf("%s.%s", schema, col.Type.Name)
+		if _, ok := gen.enums[enumQualifiedName]; !ok {
+			return core.JavaType{}, err
+		}
+
+		gen.usedEnums = append(gen.usedEnums, enumQualifiedName)
+		strJavaType = gen.conf.Package + ".enums." + codegen.EnumClassName(enumQualifiedName, gen.req.Catalog.DefaultSchema)
+		javaType.IsEnum = true
+	}
+
+	javaType.Type = strJavaType
+	return javaType, nil
+}
+
+func (gen *JavaGenerator) parseQueryReturn(col *plugin.Column) (*core.QueryReturn, error) {
+	javaType, err := gen.resolveJavaType(col)
+	if err != nil {
+		return nil, err
+	}
+	strJavaType := javaType.Type
+
+	if javaType.IsNullable && javaType.Converter == "" {
 		if javaType.IsList {
 			gen.nullableHelpers.List = true
 		} else {
@@ -144,12 +276,102 @@ func (gen *JavaGenerator) parseQueryReturn(col *plugin.Column) (*core.QueryRetur
 		}
 	}
 
+	if core.IsSqliteTemporal(gen.req.Settings.Engine, strJavaType) && javaType.Converter == "" {
+		switch strJavaType {
+		case "java.time.LocalDate":
+			gen.nullableHelpers.LocalDate = true
+		case "java.time.LocalTime":
+			gen.nullableHelpers.LocalTime = true
+		case "java.time.LocalDateTime":
+			gen.nullableHelpers.LocalDateTime = true
+		}
+	}
+
 	return &core.QueryReturn{
-		Name:     strcase.ToLowerCamel(col.Name),
+		Name:     codegen.JavaIdentifier(strcase.ToLowerCamel(col.Name)),
 		JavaType: javaType,
 	}, nil
 }
 
+// isSystemSchema returns whether the given schema is provided by the database engine, and so should not have models
+// generated for its tables.
+func isSystemSchema(schema string) bool {
+	return schema == "pg_catalog" || schema == "information_schema"
+}
+
+// modelName returns the name of the model record generated for the given table.
+func (gen *JavaGenerator) modelName(table *plugin.Table) string {
+	// TODO - fix type-writer to only exclude items that aren't part of the package name
+	if gen.conf.EmitExactTableNames {
+		return strcase.ToCamel(table.Rel.Name)
+	}
+	return strcase.ToCamel(inflection.Singular(table.Rel.Name, gen.conf.InflectionExcludeTableNames))
+}
+
+// addModel adds the model record for the given table if it does not already exist, returning the model name.
+func (gen *JavaGenerator) addModel(schema string, table *plugin.Table) (string, error) {
+	modelName := gen.modelName(table)
+	if _, ok := gen.models[modelName]; ok {
+		return modelName, nil
+	}
+
+	var modelParams []core.QueryReturn
+	for _, c := range table.Columns {
+		// catalog columns don't reference their table, which is required to find column overrides
+		if c.Table == nil {
+			c = proto.Clone(c).(*plugin.Column)
+			c.Table = &plugin.Identifier{Schema: schema, Name: table.Rel.Name}
+		}
+
+		qr, err := gen.parseQueryReturn(c)
+		if err != nil {
+			return "", errors.Join(errors.New("failed to parse query return column"), err)
+		}
+
+		modelParams = append(modelParams, *qr)
+	}
+
+	gen.models[modelName] = modelParams
+	return modelName, nil
+}
+
+// matchingModel returns the name of the model for the catalog table whose columns exactly match the given query
+// result columns, if one exists.
+func (gen *JavaGenerator) matchingModel(columns []*plugin.Column) (string, bool) {
+	for _, schema := range gen.req.Catalog.Schemas {
+		if isSystemSchema(schema.Name) {
+			continue
+		}
+
+		for _, table := range schema.Tables {
+			if columnsMatchTable(columns, schema.Name, table) {
+				return gen.modelName(table), true
+			}
+		}
+	}
+	return "", false
+}
+
+func columnsMatchTable(columns []*plugin.Column, schema string, table *plugin.Table) bool {
+	if len(columns) != len(table.Columns) {
+		return false
+	}
+
+	for i, c := range columns {
+		tc := table.Columns[i]
+		if c.EmbedTable != nil || c.Name != tc.Name || c.NotNull != tc.NotNull || c.IsArray != tc.IsArray {
+			return false
+		}
+		if sdk.DataType(c.Type) != sdk.DataType(tc.Type) {
+			return false
+		}
+		if c.Table != nil && (c.Table.Name != table.Rel.Name || (c.Table.Schema != "" && c.Table.Schema != schema)) {
+			return false
+		}
+	}
+	return true
+}
+
 func (gen *JavaGenerator) Run() (*plugin.GenerateResponse, error) {
 	// parse out the enums from the generate request
 	for _, schema := range gen.req.Catalog.Schemas {
@@ -162,6 +384,29 @@ func (gen *JavaGenerator) Run() (*plugin.GenerateResponse, error) {
 		}
 	}
 
+	if gen.conf.EmitAllModels {
+		// tables with the same name in different schemas would otherwise silently share a single model
+		modelTables := make(map[string]string)
+		for _, schema := range gen.req.Catalog.Schemas {
+			if isSystemSchema(schema.Name) {
+				continue
+			}
+
+			for _, table := range schema.Tables {
+				modelName, err := gen.addModel(schema.Name, table)
+				if err != nil {
+					return nil, err
+				}
+
+				tableName := schema.Name + "." + table.Rel.Name
+				if other, ok := modelTables[modelName]; ok {
+					return nil, fmt.Errorf("tables %s and %s would both generate the model %s", other, tableName, modelName)
+				}
+				modelTables[modelName] = tableName
+			}
+		}
+	}
+
 	// parse the incoming generate request into our Queries type
 	for _, query := range gen.req.Queries {
 		if _, ok := gen.queries[query.Filename]; !ok {
@@ -175,48 +420,49 @@ func (gen *JavaGenerator) Run() (*plugin.GenerateResponse, error) {
 
 		// TODO - enum types? other specialness?
 		args := make([]core.QueryArg, 0)
+		inferredNames := inferParamNames(gen.req.Settings.Engine, query.Text)
 		for index, arg := range query.Params {
-			isEnum := false
-			javaType, err := gen.typeConversionFunc(arg.Column.Type)
+			javaType, err := gen.resolveJavaType(arg.Column)
 			if err != nil {
-				// check if this is an enum type
-				schema := arg.Column.Table.Schema
-				if schema == "" {
-					schema = gen.req.Catalog.DefaultSchema
-				}
-
-				enumQualifiedName := fmt.Sprintf("%s.%s", schema, arg.Column.Type.Name)
-				if _, ok := gen.enums[enumQualifiedName]; !ok {
-					return nil, err
-				}
-
-				gen.usedEnums = append(gen.usedEnums, enumQualifiedName)
-				javaType = gen.conf.Package + ".enums." + codegen.EnumClassName(enumQualifiedName, gen.req.Catalog.DefaultSchema)
-				isEnum = true
-			}
-
-			if arg.Column.ArrayDims > 1 {
-				return nil, fmt.Errorf("multidimensional arrays are not supported, store JSON instead")
+				return nil, err
 			}
 
+			// explicitly named params always keep their name, otherwise prefer the name inferred from the query
 			columnName := arg.Column.Name
+			if inferred, ok := inferredNames[int(arg.Number)]; ok && !arg.Column.IsNamedParam {
+				columnName = inferred
+			}
 			if columnName == "" {
 				columnName = fmt.Sprintf("column%d", index+1)
 			}
 
+			if arg.Column.IsSqlcSlice {
+				// slices are expanded into one placeholder per element, so the list itself can never be null
+				javaType.IsList = true
+				javaType.IsNullable = false
+			}
+
 			args = append(args, core.QueryArg{
-				Number: int(arg.Number),
-				Name:   strcase.ToLowerCamel(columnName),
-				JavaType: core.JavaType{
-					SqlType:    sdk.DataType(arg.Column.Type),
-					Type:       javaType,
-					IsList:     arg.Column.IsArray,
-					IsNullable: !arg.Column.NotNull,
-					IsEnum:     isEnum,
-				},
+				Number:   int(arg.Number),
+				Name:     codegen.JavaIdentifier(strcase.ToLowerCamel(columnName)),
+				Column:   arg.Column.Name,
+				JavaType: javaType,
+				IsSlice:  arg.Column.IsSqlcSlice,
 			})
 		}
 
+		argNames := make([]nameCandidate, len(args))
+		for i, arg := range query.Params {
+			argNames[i] = nameCandidate{Name: args[i].Name, Prefix: gen.columnPrefix(arg.Column), Fixed: arg.Column.IsNamedParam}
+		}
+		for i, name := range disambiguateNames(argNames) {
+			args[i].Name = name
+		}
+
+		// embedded tables without a matching row in an outer join are returned as null instead of an empty model
+		nullableEmbeds, _ := annotationArgs(query.Comments, nullableEmbedAnnotation)
+		nullableEmbeds = append(nullableEmbeds, outerJoinedTables(query.Text)...)
+
 		// TODO - enum types? other specialness?
 		var returns []core.QueryReturn
 		for _, ret := range query.Columns {
@@ -256,55 +502,113 @@ func (gen *JavaGenerator) Run() (*plugin.GenerateResponse, error) {
 				return nil, fmt.Errorf("unknown embedded table %s.%s", schema, ret.EmbedTable)
 			}
 
-			// TODO - fix type-writer to only exclude items that aren't part of the package name
-			modelName := strcase.ToCamel(table.Rel.Name)
-			if !gen.conf.EmitExactTableNames {
-				modelName = strcase.ToCamel(inflection.Singular(table.Rel.Name, gen.conf.InflectionExcludeTableNames))
-			}
-
-			// check if we already have an entry for this model
-			if _, ok := gen.models[modelName]; !ok {
-				var modelParams []core.QueryReturn
-				for _, c := range table.Columns {
-					qr, err := gen.parseQueryReturn(c)
-					if err != nil {
-						return nil, errors.Join(errors.New("failed to parse query return column"), err)
-					}
-
-					modelParams = append(modelParams, *qr)
-				}
-
-				gen.models[modelName] = modelParams
+			modelName, err := gen.addModel(schema, table)
+			if err != nil {
+				return nil, err
 			}
 
 			returns = append(returns, core.QueryReturn{
-				Name: strcase.ToLowerCamel(modelName),
+				Name: codegen.JavaIdentifier(strcase.ToLowerCamel(modelName)),
 				JavaType: core.JavaType{
 					SqlType: "",
 					// we don't need to specify package here - models file will be generated in the same location as the queries file
 					Type:       gen.conf.Package + ".models." + modelName,
 					IsList:     false, // TODO - check: this *should* be impossible
-					IsNullable: false, // TODO - check: empty record should be output instead
+					IsNullable: slices.ContainsFunc(nullableEmbeds, func(name string) bool { return strings.EqualFold(name, table.Rel.Name) }),
 				},
 				EmbeddedModel: &modelName,
 			})
 		}
 
-		// TODO - look into fixing ? operator for postgresql JSONB operations maybe
-		newQueryText, err := gen.fixQueryPlaceholders(query.Text)
+		// reuse the model for a table instead of generating a row record when the query selects all of its columns
+		if gen.conf.EmitAllModels && len(returns) > 1 {
+			if modelName, ok := gen.matchingModel(query.Columns); ok {
+				returns = []core.QueryReturn{{
+					Name:          codegen.JavaIdentifier(strcase.ToLowerCamel(modelName)),
+					JavaType:      core.JavaType{Type: gen.conf.Package + ".models." + modelName},
+					EmbeddedModel: &modelName,
+				}}
+			}
+		}
+
+		// columns with the same name, e.g. from joined tables, would otherwise produce duplicate record components
+		returnNames := make([]nameCandidate, len(returns))
+		for i, ret := range returns {
+			returnNames[i] = nameCandidate{Name: ret.Name}
+			if ret.EmbeddedModel == nil {
+				returnNames[i].Prefix = gen.columnPrefix(query.Columns[i])
+			}
+		}
+		for i, name := range disambiguateNames(returnNames) {
+			returns[i].Name = name
+		}
+
+		if command.IsBatch() && slices.ContainsFunc(args, func(arg core.QueryArg) bool { return arg.IsSlice }) {
+			return nil, fmt.Errorf("query %s: sqlc.slice is not supported by batch queries", query.Name)
+		}
+		// the vertx postgres client does not report generated keys, so they can only be read from the rows returned
+		if command == core.ExecResult && gen.conf.Backend == core.BackendVertx && gen.req.Settings.Engine == "postgresql" &&
+			!returningRegexp.MatchString(query.Text) {
+			return nil, fmt.Errorf("query %s: :execresult queries must use a RETURNING clause with the vertx backend", query.Name)
+		}
+		// JDBC batches cannot return result sets, the rows can only be read from the generated keys which only pgjdbc
+		// populates with the rows returned by a RETURNING clause
+		batchReturning := (command == core.BatchOne || command == core.BatchMany) &&
+			gen.req.Settings.Engine == "postgresql" && returningRegexp.MatchString(query.Text)
+
+		var insertIntoTable string
+		if command == core.CopyFrom {
+			if query.InsertIntoTable == nil {
+				return nil, fmt.Errorf("query %s: copyfrom queries must insert into a table", query.Name)
+			}
+
+			insertIntoTable = query.InsertIntoTable.Name
+			if query.InsertIntoTable.Schema != "" {
+				insertIntoTable = query.InsertIntoTable.Schema + "." + insertIntoTable
+			}
+		}
+
+		newQueryText, placeholders, err := gen.fixQueryPlaceholders(query.Text)
 		if err != nil {
 			return nil, err
 		}
+		// r2dbc-postgresql binds the string value of an enum as a varchar, which postgres will not implicitly convert
+		if gen.conf.Backend == core.BackendR2dbc && gen.req.Settings.Engine == "postgresql" {
+			casts := make(map[int]string)
+			for _, arg := range args {
+				if !arg.JavaType.IsEnum {
+					continue
+				}
+
+				casts[arg.Number] = postgresTypeName(arg.JavaType.SqlType)
+				if arg.JavaType.IsList {
+					casts[arg.Number] += "[]"
+				}
+			}
+
+			if newQueryText, err = castPostgresPlaceholders(newQueryText, casts); err != nil {
+				return nil, err
+			}
+		}
+		for _, number := range placeholders {
+			if !slices.ContainsFunc(args, func(arg core.QueryArg) bool { return arg.Number == number }) {
+				return nil, fmt.Errorf("query %s: placeholder $%d does not match any parameter", query.Name, number)
+			}
+		}
 
 		gen.queries[query.Filename] = append(gen.queries[query.Filename], core.Query{
-			RawCommand:   query.Cmd,
-			Command:      command,
-			Text:         newQueryText,
-			RawQueryName: query.Name,
-			// TODO - clean the name of any disallowed characters?
-			MethodName: strcase.ToLowerCamel(query.Name),
-			Args:       args,
-			Returns:    returns,
+			RawCommand:      query.Cmd,
+			Command:         command,
+			Text:            newQueryText,
+			RawQueryName:    query.Name,
+			MethodName:      codegen.JavaIdentifier(strcase.ToLowerCamel(query.Name)),
+			Args:            args,
+			Returns:         returns,
+			InsertIntoTable: insertIntoTable,
+			Placeholders:    placeholders,
+			Stream:          command == core.Many && (gen.conf.EmitStreams || hasAnnotation(query.Comments, streamAnnotation)),
+			BatchReturning:  batchReturning,
+			UseParamsRecord: gen.conf.QueryParameterLimit != nil && len(args) > *gen.conf.QueryParameterLimit,
 		})
 	}
 
@@ -314,7 +618,16 @@ func (gen *JavaGenerator) Run() (*plugin.GenerateResponse, error) {
 		slices.SortFunc(gen.queries[file], func(a, b core.Query) int { return strings.Compare(a.MethodName, b.MethodName) })
 
 		// build the queries file contents
-		fileName, fileContents, err := codegen.BuildQueriesFile(gen.req.Settings.Engine, gen.conf, file, gen.queries[file], gen.models, gen.nullableHelpers)
+		buildQueriesFile := codegen.BuildQueriesFile
+		switch gen.conf.Backend {
+		case core.BackendSpringJdbc:
+			buildQueriesFile = codegen.BuildSpringQueriesFile
+		case core.BackendR2dbc:
+			buildQueriesFile = codegen.BuildR2dbcQueriesFile
+		case core.BackendVertx:
+			buildQueriesFile = codegen.BuildVertxQueriesFile
+		}
+		fileName, fileContents, err := buildQueriesFile(gen.req.Settings.Engine, gen.conf, file, gen.queries[file], gen.models, gen.nullableHelpers)
 		if err != nil {
 			return nil, err
 		}
@@ -322,10 +635,25 @@ func (gen *JavaGenerator) Run() (*plugin.GenerateResponse, error) {
 			Name:     fileName,
 			Contents: fileContents,
 		})
+
+		if gen.conf.EmitInterface {
+			fileName, fileContents, err := codegen.BuildQuerierFile(gen.conf, file, gen.queries[file])
+			if err != nil {
+				return nil, err
+			}
+			outputFiles = append(outputFiles, &plugin.File{
+				Name:     fileName,
+				Contents: fileContents,
+			})
+		}
 	}
 
+	modelNames := make([]string, 0, len(gen.models))
+	for modelName := range gen.models {
+		modelNames = append(modelNames, strcase.ToCamel(modelName))
+	}
 	for modelName, model := range gen.models {
-		fileName, fileContents, err := codegen.BuildModelFile(gen.conf, modelName, model)
+		fileName, fileContents, err := codegen.BuildModelFile(gen.conf, modelName, model, modelNames)
 		if err != nil {
 			return nil, err
 		}
@@ -337,7 +665,7 @@ func (gen *JavaGenerator) Run() (*plugin.GenerateResponse, error) {
 
 	// remove duplicate enum entries
 	slices.Sort(gen.usedEnums)
-	slices.Compact(gen.usedEnums)
+	gen.usedEnums = slices.Compact(gen.usedEnums)
 	for _, qualName := range gen.usedEnums {
 		if qualName == "" {
 			continue
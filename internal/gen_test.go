@@ -0,0 +1,634 @@
+package internal
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sqlc-dev/plugin-sdk-go/plugin"
+)
+
+func testGenerator(engine string) *JavaGenerator {
+	return &JavaGenerator{req: &plugin.GenerateRequest{Settings: &plugin.Settings{Engine: engine}}}
+}
+
+func TestFixQueryPlaceholders(t *testing.T) {
+	cases := []struct {
+		Name                 string
+		Query                string
+		ExpectedQuery        string
+		ExpectedPlaceholders []int
+	}{
+		{"sequential", "SELECT * FROM foo WHERE a = $1 AND b = $2", "SELECT * FROM foo WHERE a = ? AND b = ?", []int{1, 2}},
+		{"reused", "SELECT * FROM foo WHERE a = $1 OR b = $1", "SELECT * FROM foo WHERE a = ? OR b = ?", []int{1, 1}},
+		{"reordered", "UPDATE foo SET a = $2 WHERE id = $1", "UPDATE foo SET a = ? WHERE id = ?", []int{2, 1}},
+		{"reused and reordered", "SELECT * FROM foo WHERE a = $2 AND (b = $1 OR c = $2)", "SELECT * FROM foo WHERE a = ? AND (b = ? OR c = ?)", []int{2, 1, 2}},
+		{"multiple digits", "SELECT * FROM foo WHERE a = $10", "SELECT * FROM foo WHERE a = ?", []int{10}},
+		{"no placeholders", "SELECT * FROM foo", "SELECT * FROM foo", []int{}},
+		{"jsonb key exists", "SELECT * FROM foo WHERE flags ? 'beta' AND id = $1", "SELECT * FROM foo WHERE flags ?? 'beta' AND id = ?", []int{1}},
+		{"jsonb any and all keys exist", "SELECT * FROM foo WHERE a ?| $1 AND b ?& $2", "SELECT * FROM foo WHERE a ??| ? AND b ??& ?", []int{1, 2}},
+		{"string literals", "SELECT 'what? $1', E'it\\'s ?', 'it''s ?' FROM foo WHERE a = $1", "SELECT 'what? $1', E'it\\'s ?', 'it''s ?' FROM foo WHERE a = ?", []int{1}},
+		{"quoted identifiers", `SELECT "odd?$1" FROM foo WHERE a = $1`, `SELECT "odd?$1" FROM foo WHERE a = ?`, []int{1}},
+		{"line comments", "SELECT * FROM foo -- why? $2\nWHERE a = $1", "SELECT * FROM foo -- why? $2\nWHERE a = ?", []int{1}},
+		{"block comments", "SELECT * /* why? /* $2 */ ? */ FROM foo WHERE a = $1", "SELECT * /* why? /* $2 */ ? */ FROM foo WHERE a = ?", []int{1}},
+		{"dollar quoted", "SELECT $$what? $1$$, $tag$ $$ ? $tag$ FROM foo WHERE a = $1", "SELECT $$what? $1$$, $tag$ $$ ? $tag$ FROM foo WHERE a = ?", []int{1}},
+		{"dollar in identifier", "SELECT foo$1 FROM foo WHERE a = $1", "SELECT foo$1 FROM foo WHERE a = ?", []int{1}},
+	}
+
+	gen := testGenerator("postgresql")
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			query, placeholders, err := gen.fixQueryPlaceholders(c.Query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if query != c.ExpectedQuery {
+				t.Errorf("expected query '%s', got '%s'", c.ExpectedQuery, query)
+			}
+			if !slices.Equal(placeholders, c.ExpectedPlaceholders) {
+				t.Errorf("expected placeholders %v, got %v", c.ExpectedPlaceholders, placeholders)
+			}
+		})
+	}
+}
+
+func TestFixQueryPlaceholdersIgnoresOtherEngines(t *testing.T) {
+	for _, engine := range []string{"mysql", "sqlite"} {
+		query, placeholders, err := testGenerator(engine).fixQueryPlaceholders("SELECT * FROM foo WHERE a = ? AND b = ?")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != "SELECT * FROM foo WHERE a = ? AND b = ?" {
+			t.Errorf("%s: query should not be modified, got '%s'", engine, query)
+		}
+		if placeholders != nil {
+			t.Errorf("%s: expected no placeholder mapping, got %v", engine, placeholders)
+		}
+	}
+}
+
+func TestR2dbcEnumPlaceholdersCast(t *testing.T) {
+	moods := testColumn("moods", "mood", true, "")
+	moods.IsArray = true
+
+	req := &plugin.GenerateRequest{
+		Settings:      &plugin.Settings{Engine: "postgresql"},
+		PluginOptions: []byte(`{"package": "com.example", "backend": "r2dbc"}`),
+		Catalog: &plugin.Catalog{DefaultSchema: "public", Schemas: []*plugin.Schema{{
+			Name:  "public",
+			Enums: []*plugin.Enum{{Name: "mood", Vals: []string{"sad", "happy"}}, {Name: "Feeling", Vals: []string{"ok"}}},
+		}}},
+		Queries: []*plugin.Query{{
+			Name: "CreatePerson", Cmd: ":exec", Filename: "queries.sql",
+			Text: "INSERT INTO person (name, mood, feeling) SELECT $1, $2, $4 WHERE $2 = ANY($3) AND name != '$2'",
+			Params: []*plugin.Parameter{
+				{Number: 1, Column: testColumn("name", "text", true, "person")},
+				{Number: 2, Column: testColumn("mood", "mood", true, "person")},
+				{Number: 3, Column: moods},
+				{Number: 4, Column: testColumn("feeling", "Feeling", false, "person")},
+			},
+		}},
+	}
+
+	queries := generateFiles(t, req)["Queries.java"]
+	expected := `INSERT INTO person (name, mood, feeling) SELECT $1, $2::mood, $4::"Feeling" WHERE $2::mood = ANY($3::mood[]) AND name != '$2'`
+	if !strings.Contains(queries, expected) {
+		t.Errorf("expected enum placeholders to be cast:\n%s", queries)
+	}
+
+	req.PluginOptions = []byte(`{"package": "com.example", "backend": "vertx"}`)
+	if queries := generateFiles(t, req)["Queries.java"]; strings.Contains(queries, "::mood") {
+		t.Errorf("expected no casts for the vertx backend:\n%s", queries)
+	}
+}
+
+func TestSqliteUntypedColumns(t *testing.T) {
+	for _, colType := range []string{"", "any"} {
+		req := &plugin.GenerateRequest{
+			Settings:      &plugin.Settings{Engine: "sqlite"},
+			PluginOptions: []byte(`{"package": "com.example"}`),
+			Catalog: &plugin.Catalog{DefaultSchema: "main", Schemas: []*plugin.Schema{
+				{Name: "main", Tables: []*plugin.Table{
+					{Rel: &plugin.Identifier{Name: "kv"}, Columns: []*plugin.Column{testColumn("value", colType, false, "")}},
+				}},
+			}},
+			Queries: []*plugin.Query{{
+				Name: "GetValue", Cmd: ":one", Filename: "queries.sql", Text: "SELECT value FROM kv WHERE value = ?",
+				Columns: []*plugin.Column{testColumn("value", colType, false, "kv")},
+				Params:  []*plugin.Parameter{{Number: 1, Column: testColumn("value", colType, false, "kv")}},
+			}},
+		}
+
+		queries := generateFiles(t, req)["Queries.java"]
+		for _, expected := range []string{
+			"@Nullable Object value",
+			"stmt.setObject(1, value);",
+			"results.getObject(1)",
+		} {
+			if !strings.Contains(queries, expected) {
+				t.Errorf("type '%s': expected '%s' in output:\n%s", colType, expected, queries)
+			}
+		}
+	}
+}
+
+func TestHasAnnotation(t *testing.T) {
+	cases := []struct {
+		Comments []string
+		Expected bool
+	}{
+		{nil, false},
+		{[]string{"Lists every user"}, false},
+		{[]string{" @stream"}, true},
+		{[]string{"Lists every user", "-- @stream "}, true},
+		{[]string{"@streaming"}, false},
+		{[]string{"use @stream for large exports"}, false},
+	}
+
+	for _, c := range cases {
+		if actual := hasAnnotation(c.Comments, "@stream"); actual != c.Expected {
+			t.Errorf("%q: expected %v, got %v", c.Comments, c.Expected, actual)
+		}
+	}
+}
+
+func TestAnnotationArgs(t *testing.T) {
+	args, found := annotationArgs([]string{" @nullable_embed tokens", "Gets a user", "-- @nullable_embed books authors"}, "@nullable_embed")
+	if !found {
+		t.Fatal("expected annotation to be found")
+	}
+	if !slices.Equal(args, []string{"tokens", "books", "authors"}) {
+		t.Errorf("expected [tokens books authors], got %v", args)
+	}
+
+	if _, found := annotationArgs([]string{"@nullable_embeds tokens"}, "@nullable_embed"); found {
+		t.Error("expected annotation not to be found")
+	}
+}
+
+func TestOuterJoinedTables(t *testing.T) {
+	cases := []struct {
+		Query    string
+		Expected []string
+	}{
+		{"SELECT * FROM users JOIN tokens ON tokens.user_id = users.user_id", []string{}},
+		{"SELECT * FROM users INNER JOIN tokens ON tokens.user_id = users.user_id", []string{}},
+		{"SELECT * FROM users LEFT JOIN tokens ON tokens.user_id = users.user_id", []string{"tokens"}},
+		{"SELECT * FROM users left outer join public.tokens t ON t.user_id = users.user_id", []string{"tokens"}},
+		{"SELECT * FROM users JOIN tokens ON true RIGHT JOIN sessions ON true", []string{"users", "tokens"}},
+		{"SELECT * FROM users FULL JOIN tokens ON true", []string{"tokens", "users"}},
+	}
+
+	for _, c := range cases {
+		if actual := outerJoinedTables(c.Query); !slices.Equal(actual, c.Expected) {
+			t.Errorf("%s: expected %v, got %v", c.Query, c.Expected, actual)
+		}
+	}
+}
+
+func testColumn(name, typ string, notNull bool, table string) *plugin.Column {
+	c := &plugin.Column{Name: name, Type: &plugin.Identifier{Name: typ}, NotNull: notNull}
+	if table != "" {
+		c.Table = &plugin.Identifier{Name: table}
+	}
+	return c
+}
+
+// generateFiles runs the generator for the given request, returning the contents of each generated file by name.
+func generateFiles(t *testing.T, req *plugin.GenerateRequest) map[string]string {
+	t.Helper()
+
+	resp, err := Generate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := make(map[string]string)
+	for _, f := range resp.Files {
+		files[f.Name] = string(f.Contents)
+	}
+	return files
+}
+
+func TestEmitAllModels(t *testing.T) {
+	req := &plugin.GenerateRequest{
+		Settings:      &plugin.Settings{Engine: "postgresql"},
+		PluginOptions: []byte(`{"package": "com.example", "emit_all_models": true}`),
+		Catalog: &plugin.Catalog{DefaultSchema: "public", Schemas: []*plugin.Schema{
+			{Name: "public", Tables: []*plugin.Table{
+				{Rel: &plugin.Identifier{Name: "users"}, Columns: []*plugin.Column{
+					testColumn("user_id", "int4", true, ""),
+					testColumn("email", "text", true, ""),
+				}},
+				{Rel: &plugin.Identifier{Name: "tokens"}, Columns: []*plugin.Column{
+					testColumn("token", "text", true, ""),
+				}},
+			}},
+			{Name: "audit", Tables: []*plugin.Table{
+				{Rel: &plugin.Identifier{Name: "events"}, Columns: []*plugin.Column{
+					testColumn("event_id", "int4", true, ""),
+				}},
+			}},
+			{Name: "pg_catalog", Tables: []*plugin.Table{
+				{Rel: &plugin.Identifier{Name: "pg_class"}, Columns: []*plugin.Column{
+					testColumn("oid", "oid", true, ""),
+				}},
+			}},
+		}},
+		Queries: []*plugin.Query{
+			{
+				Name: "GetUser", Cmd: ":one", Filename: "queries.sql", Text: "SELECT * FROM users WHERE user_id = $1",
+				Columns: []*plugin.Column{testColumn("user_id", "int4", true, "users"), testColumn("email", "text", true, "users")},
+				Params:  []*plugin.Parameter{{Number: 1, Column: testColumn("user_id", "int4", true, "users")}},
+			},
+			{
+				Name: "GetUserEmail", Cmd: ":one", Filename: "queries.sql", Text: "SELECT user_id, lower(email) AS email FROM users",
+				Columns: []*plugin.Column{testColumn("user_id", "int4", true, "users"), testColumn("email", "text", false, "")},
+			},
+		},
+	}
+
+	files := generateFiles(t, req)
+
+	for _, name := range []string{"models/User.java", "models/Token.java", "models/Event.java"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("expected %s to be generated", name)
+		}
+	}
+	if _, ok := files["models/PgClass.java"]; ok {
+		t.Error("expected no model to be generated for system tables")
+	}
+
+	queries := files["Queries.java"]
+	if !strings.Contains(queries, "public Optional<User> getUser(") {
+		t.Errorf("expected getUser to return the User model:\n%s", queries)
+	}
+	if strings.Contains(queries, "GetUserRow") {
+		t.Errorf("expected no row record for getUser:\n%s", queries)
+	}
+	if !strings.Contains(queries, "public Optional<GetUserEmailRow> getUserEmail(") {
+		t.Errorf("expected getUserEmail to return a row record:\n%s", queries)
+	}
+}
+
+func TestEmitAllModelsRejectsCollidingTables(t *testing.T) {
+	users := &plugin.Table{Rel: &plugin.Identifier{Name: "users"}, Columns: []*plugin.Column{testColumn("user_id", "int4", true, "")}}
+	req := &plugin.GenerateRequest{
+		Settings:      &plugin.Settings{Engine: "postgresql"},
+		PluginOptions: []byte(`{"package": "com.example", "emit_all_models": true}`),
+		Catalog: &plugin.Catalog{DefaultSchema: "public", Schemas: []*plugin.Schema{
+			{Name: "public", Tables: []*plugin.Table{users}},
+			{Name: "audit", Tables: []*plugin.Table{users}},
+		}},
+	}
+
+	_, err := Generate(context.Background(), req)
+	if err == nil || !strings.Contains(err.Error(), "public.users and audit.users") {
+		t.Errorf("expected an error naming the colliding tables, got %v", err)
+	}
+}
+
+func TestReservedWordsEscaped(t *testing.T) {
+	req := &plugin.GenerateRequest{
+		Settings:      &plugin.Settings{Engine: "postgresql"},
+		PluginOptions: []byte(`{"package": "com.example"}`),
+		Catalog:       &plugin.Catalog{DefaultSchema: "public"},
+		Queries: []*plugin.Query{{
+			Name: "New", Cmd: ":many", Filename: "queries.sql", Text: "SELECT class, package FROM classes WHERE default = $1",
+			Columns: []*plugin.Column{testColumn("class", "text", true, "classes"), testColumn("package", "text", true, "classes")},
+			Params:  []*plugin.Parameter{{Number: 1, Column: testColumn("default", "bool", true, "classes")}},
+		}},
+	}
+
+	queries := generateFiles(t, req)["Queries.java"]
+	for _, expected := range []string{
+		"public record NewRow(",
+		"@NonNull String class_,",
+		"@NonNull String package_",
+		"public List<NewRow> new_(",
+		"boolean default_",
+		"stmt.setBoolean(1, default_);",
+	} {
+		if !strings.Contains(queries, expected) {
+			t.Errorf("expected output to contain %q:\n%s", expected, queries)
+		}
+	}
+}
+
+func TestDisambiguateNames(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []nameCandidate
+		expected   []string
+	}{
+		{
+			"unique names unchanged",
+			[]nameCandidate{{Name: "userId", Prefix: "user"}, {Name: "email", Prefix: "user"}},
+			[]string{"userId", "email"},
+		},
+		{
+			"same table uses numeric suffix",
+			[]nameCandidate{{Name: "createdAt", Prefix: "user"}, {Name: "createdAt", Prefix: "user"}, {Name: "createdAt", Prefix: "user"}},
+			[]string{"createdAt", "createdAt2", "createdAt3"},
+		},
+		{
+			"different tables use table prefix",
+			[]nameCandidate{{Name: "id", Prefix: "user"}, {Name: "id", Prefix: "token"}},
+			[]string{"userId", "tokenId"},
+		},
+		{
+			"named params take precedence",
+			[]nameCandidate{{Name: "createdAt"}, {Name: "createdAt", Fixed: true}},
+			[]string{"createdAt2", "createdAt"},
+		},
+		{
+			"named params take precedence over table prefix",
+			[]nameCandidate{{Name: "createdAt", Prefix: "user"}, {Name: "createdAt", Fixed: true}},
+			[]string{"userCreatedAt", "createdAt"},
+		},
+		{
+			"suffix skips taken names",
+			[]nameCandidate{{Name: "id"}, {Name: "id"}, {Name: "id2"}},
+			[]string{"id", "id3", "id2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := disambiguateNames(test.candidates); !slices.Equal(got, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestCollidingNamesDisambiguated(t *testing.T) {
+	req := &plugin.GenerateRequest{
+		Settings:      &plugin.Settings{Engine: "postgresql"},
+		PluginOptions: []byte(`{"package": "com.example"}`),
+		Catalog:       &plugin.Catalog{DefaultSchema: "public"},
+		Queries: []*plugin.Query{{
+			Name: "ListUserTokens", Cmd: ":many", Filename: "queries.sql",
+			Text:    "SELECT u.id, t.id FROM users u JOIN tokens t ON t.user_id = u.id WHERE u.created_at > $1 AND u.created_at < $2",
+			Columns: []*plugin.Column{testColumn("id", "int4", true, "users"), testColumn("id", "int4", true, "tokens")},
+			Params: []*plugin.Parameter{
+				{Number: 1, Column: testColumn("created_at", "timestamp", true, "users")},
+				{Number: 2, Column: testColumn("created_at", "timestamp", true, "users")},
+			},
+		}},
+	}
+
+	queries := generateFiles(t, req)["Queries.java"]
+	for _, expected := range []string{
+		"int userId,",
+		"int tokenId",
+		"LocalDateTime createdAt,",
+		"LocalDateTime createdAt2",
+	} {
+		if !strings.Contains(queries, expected) {
+			t.Errorf("expected output to contain %q:\n%s", expected, queries)
+		}
+	}
+}
+
+func TestInferParamNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		engine   string
+		query    string
+		expected map[int]string
+	}{
+		{
+			"limit and offset", "postgresql",
+			"SELECT * FROM users ORDER BY id LIMIT $1 OFFSET $2",
+			map[int]string{1: "limit", 2: "offset"},
+		},
+		{
+			"function call operand", "postgresql",
+			"SELECT * FROM users WHERE lower(email) = $1",
+			map[int]string{1: "email"},
+		},
+		{
+			"wrapped placeholder", "postgresql",
+			"SELECT * FROM users WHERE u.email = lower($1::text) AND id = ANY($2::int[])",
+			map[int]string{1: "email", 2: "id"},
+		},
+		{
+			"placeholder on left", "postgresql",
+			"SELECT * FROM users WHERE $1 <= created_at AND name NOT LIKE $2",
+			map[int]string{1: "created_at", 2: "name"},
+		},
+		{
+			"insert values", "postgresql",
+			"INSERT INTO users (email, name, created_at) VALUES ($1, lower($2), now()), ($3, $4, now()) ON CONFLICT (email) DO UPDATE SET name = $5 RETURNING id",
+			map[int]string{1: "email", 2: "name", 3: "email", 4: "name", 5: "name"},
+		},
+		{
+			"literals and comments ignored", "postgresql",
+			"SELECT * FROM users WHERE name = '$1 = foo' /* bar = $2 */ AND $1 > 0",
+			map[int]string{},
+		},
+		{
+			"positional placeholders", "mysql",
+			"SELECT * FROM users WHERE name = 'what?' AND email = ? LIMIT ?",
+			map[int]string{1: "email", 2: "limit"},
+		},
+		{
+			"numbered positional placeholders", "sqlite",
+			"SELECT * FROM users WHERE email = ?2 AND name = ?1",
+			map[int]string{1: "name", 2: "email"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := inferParamNames(test.engine, test.query)
+			if !maps.Equal(got, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestParamNamesPreferNamedParams(t *testing.T) {
+	named := testColumn("created", "timestamp", true, "")
+	named.IsNamedParam = true
+
+	req := &plugin.GenerateRequest{
+		Settings:      &plugin.Settings{Engine: "postgresql"},
+		PluginOptions: []byte(`{"package": "com.example"}`),
+		Catalog:       &plugin.Catalog{DefaultSchema: "public"},
+		Queries: []*plugin.Query{{
+			Name: "ListUsers", Cmd: ":many", Filename: "queries.sql",
+			Text:    "SELECT id FROM users WHERE created_at > $1 AND lower(email) = $2 AND $3 LIMIT $4",
+			Columns: []*plugin.Column{testColumn("id", "int4", true, "users")},
+			Params: []*plugin.Parameter{
+				{Number: 1, Column: named},
+				{Number: 2, Column: testColumn("lower", "text", true, "")},
+				{Number: 3, Column: testColumn("", "bool", true, "")},
+				{Number: 4, Column: testColumn("", "int4", true, "")},
+			},
+		}},
+	}
+
+	queries := generateFiles(t, req)["Queries.java"]
+	for _, expected := range []string{
+		"LocalDateTime created,",
+		"String email,",
+		"boolean column3,",
+		"int limit",
+	} {
+		if !strings.Contains(queries, expected) {
+			t.Errorf("expected output to contain %q:\n%s", expected, queries)
+		}
+	}
+}
+
+func TestBackendValidated(t *testing.T) {
+	for _, test := range []struct {
+		engine  string
+		options string
+	}{
+		{"postgresql", `{"package": "com.example", "backend": "hibernate"}`},
+		{"postgresql", `{"package": "com.example", "backend": "spring-jdbc", "emit_datasource_constructor": true}`},
+		{"postgresql", `{"package": "com.example", "backend": "r2dbc", "emit_datasource_constructor": true}`},
+		{"sqlite", `{"package": "com.example", "backend": "r2dbc"}`},
+		{"sqlite", `{"package": "com.example", "backend": "vertx"}`},
+		{"postgresql", `{"package": "com.example", "backend": "r2dbc", "emit_async": true}`},
+		{"postgresql", `{"package": "com.example", "model_style": "bean"}`},
+	} {
+		req := &plugin.GenerateRequest{Settings: &plugin.Settings{Engine: test.engine}, PluginOptions: []byte(test.options)}
+		if _, err := NewJavaGenerator(req); err == nil {
+			t.Errorf("%s %s: expected an error", test.engine, test.options)
+		}
+	}
+}
+
+func TestBatchReturningDetected(t *testing.T) {
+	for _, test := range []struct {
+		engine   string
+		idType   string
+		text     string
+		expected bool
+	}{
+		{"postgresql", "int4", "INSERT INTO users (email) VALUES ($1) RETURNING id", true},
+		{"postgresql", "int4", "SELECT id FROM users WHERE email = $1", false},
+		{"mysql", "int", "SELECT id FROM users WHERE email = ?", false},
+		{"sqlite", "integer", "INSERT INTO users (email) VALUES (?) RETURNING id", false},
+	} {
+		for _, cmd := range []string{":batchone", ":batchmany"} {
+			req := &plugin.GenerateRequest{
+				Settings:      &plugin.Settings{Engine: test.engine},
+				PluginOptions: []byte(`{"package": "com.example"}`),
+				Catalog:       &plugin.Catalog{DefaultSchema: "public"},
+				Queries: []*plugin.Query{{
+					Name: "CreateUsers", Cmd: cmd, Filename: "queries.sql", Text: test.text,
+					Columns: []*plugin.Column{testColumn("id", test.idType, true, "users")},
+					Params:  []*plugin.Parameter{{Number: 1, Column: testColumn("email", "text", true, "users")}},
+				}},
+			}
+
+			queries := generateFiles(t, req)["Queries.java"]
+			if actual := strings.Contains(queries, "RETURN_GENERATED_KEYS"); actual != test.expected {
+				t.Errorf("%s %s %q: expected rows read from generated keys %v, got %v", test.engine, cmd, test.text, test.expected, actual)
+			}
+		}
+	}
+}
+
+func TestVertxExecResultRequiresReturning(t *testing.T) {
+	for _, test := range []struct {
+		engine  string
+		text    string
+		allowed bool
+	}{
+		{"postgresql", "INSERT INTO users (email) VALUES ($1) RETURNING id", true},
+		{"postgresql", "INSERT INTO users (email) VALUES ($1)", false},
+		{"mysql", "INSERT INTO users (email) VALUES (?)", true},
+	} {
+		req := &plugin.GenerateRequest{
+			Settings:      &plugin.Settings{Engine: test.engine},
+			PluginOptions: []byte(`{"package": "com.example", "backend": "vertx"}`),
+			Catalog:       &plugin.Catalog{DefaultSchema: "public"},
+			Queries: []*plugin.Query{{
+				Name: "CreateUser", Cmd: ":execresult", Filename: "queries.sql", Text: test.text,
+				Params: []*plugin.Parameter{{Number: 1, Column: testColumn("email", "text", true, "users")}},
+			}},
+		}
+
+		if _, err := Generate(context.Background(), req); (err == nil) != test.allowed {
+			t.Errorf("%s %q: expected allowed %v, got error %v", test.engine, test.text, test.allowed, err)
+		}
+	}
+}
+
+func TestResolveJavaTypeOverrides(t *testing.T) {
+	options := `{"package": "com.example", "overrides": [
+		{"db_type": "text", "java_type": "com.example.Text"},
+		{"db_type": "pg_catalog.int4", "java_type": "com.example.Int"},
+		{"column": "users.email", "java_type": "com.example.Email"},
+		{"column": "public.users.name", "java_type": "com.example.Name"},
+		{"column": "users.tags", "java_type": "com.example.Tag", "converter": "com.example.TagConverter"}
+	]}`
+	req := &plugin.GenerateRequest{
+		Settings:      &plugin.Settings{Engine: "postgresql"},
+		Catalog:       &plugin.Catalog{DefaultSchema: "public"},
+		PluginOptions: []byte(options),
+	}
+	gen, err := NewJavaGenerator(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	column := func(schema, table, name, originalName, typ string, notNull bool) *plugin.Column {
+		return &plugin.Column{
+			Name:         name,
+			OriginalName: originalName,
+			Type:         &plugin.Identifier{Name: typ},
+			NotNull:      notNull,
+			Table:        &plugin.Identifier{Schema: schema, Name: table},
+		}
+	}
+	int4 := column("", "users", "id", "", "int4", true)
+	int4.Type.Schema = "pg_catalog"
+	tags := column("", "users", "tags", "", "text", true)
+	tags.IsArray = true
+
+	cases := []struct {
+		Name       string
+		Column     *plugin.Column
+		Type       string
+		IsNullable bool
+		Error      string
+	}{
+		{"column override beats db_type", column("", "users", "email", "", "text", true), "com.example.Email", false, ""},
+		{"db_type override", column("", "users", "bio", "", "text", true), "com.example.Text", false, ""},
+		{"schema qualified db_type", int4, "com.example.Int", false, ""},
+		{"unqualified column in default schema", column("public", "users", "email", "", "text", true), "com.example.Email", false, ""},
+		{"unqualified column in other schema", column("audit", "users", "email", "", "text", true), "com.example.Email", false, ""},
+		{"qualified column in default schema", column("", "users", "name", "", "text", true), "com.example.Name", false, ""},
+		{"qualified column in other schema", column("audit", "users", "name", "", "text", true), "com.example.Text", false, ""},
+		{"aliased column matched by original name", column("", "users", "contact", "email", "text", true), "com.example.Email", false, ""},
+		{"nullable column", column("", "users", "email", "", "text", false), "com.example.Email", true, ""},
+		{"converter on array column", tags, "", false, "converters are not supported for array types"},
+	}
+
+	for _, c := range cases {
+		javaType, err := gen.resolveJavaType(c.Column)
+		if c.Error != "" {
+			if err == nil || !strings.Contains(err.Error(), c.Error) {
+				t.Errorf("%s: expected error containing %q, got %v", c.Name, c.Error, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.Name, err)
+			continue
+		}
+		if javaType.Type != c.Type || javaType.IsNullable != c.IsNullable {
+			t.Errorf("%s: expected %s (nullable %t), got %s (nullable %t)", c.Name, c.Type, c.IsNullable, javaType.Type, javaType.IsNullable)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"strconv"
+
+	"github.com/iancoleman/strcase"
+	"github.com/sqlc-dev/plugin-sdk-go/plugin"
+	"github.com/tandemdude/sqlc-gen-java/internal/codegen"
+	"github.com/tandemdude/sqlc-gen-java/internal/inflection"
+)
+
+// nameCandidate is the preferred name of a generated parameter or record component, along with the information used
+// to distinguish it from others with the same name.
+type nameCandidate struct {
+	Name string
+	// Prefix is prepended to the name if it collides with another, e.g. the name of the table the column belongs to.
+	Prefix string
+	// Fixed is whether the name was explicitly chosen using sqlc.arg or @name, and so must never be changed.
+	Fixed bool
+}
+
+// disambiguateNames returns a unique name for each of the given candidates. Colliding names are first distinguished
+// using their prefixes, if the prefixes differ, and then by appending a numeric suffix to every occurrence after the
+// first. Fixed names always take precedence over those which were inferred.
+func disambiguateNames(candidates []nameCandidate) []string {
+	groups := make(map[string][]int)
+	for i, c := range candidates {
+		groups[c.Name] = append(groups[c.Name], i)
+	}
+
+	names := make([]string, len(candidates))
+	for i, c := range candidates {
+		names[i] = c.Name
+
+		group := groups[c.Name]
+		if c.Fixed || c.Prefix == "" || len(group) < 2 {
+			continue
+		}
+
+		distinguishable := false
+		for _, j := range group {
+			if candidates[j].Fixed || candidates[j].Prefix != c.Prefix {
+				distinguishable = true
+				break
+			}
+		}
+		if distinguishable {
+			names[i] = codegen.JavaIdentifier(strcase.ToLowerCamel(c.Prefix + "_" + c.Name))
+		}
+	}
+
+	// names which are already unique are never changed, so must not be reused as suffixed names either
+	counts := make(map[string]int)
+	for _, name := range names {
+		counts[name]++
+	}
+	taken := make(map[string]bool)
+	for i, c := range candidates {
+		if c.Fixed || counts[names[i]] == 1 {
+			taken[names[i]] = true
+		}
+	}
+
+	for i, c := range candidates {
+		if c.Fixed || counts[names[i]] == 1 {
+			continue
+		}
+
+		name := names[i]
+		for n := 2; taken[name]; n++ {
+			name = names[i] + strconv.Itoa(n)
+		}
+		names[i] = name
+		taken[name] = true
+	}
+	return names
+}
+
+// columnPrefix returns the prefix used to distinguish the given column from others with the same name. This is the
+// name of the table the column belongs to, if known.
+func (gen *JavaGenerator) columnPrefix(col *plugin.Column) string {
+	if col == nil || col.Table == nil {
+		return ""
+	}
+
+	name := col.Table.Name
+	if !gen.conf.EmitExactTableNames {
+		name = inflection.Singular(name, gen.conf.InflectionExcludeTableNames)
+	}
+	return name
+}
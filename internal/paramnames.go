@@ -0,0 +1,298 @@
+package internal
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// sqlToken is a single token of a query. Placeholder is the parameter number referenced by the token, or zero if the
+// token is not a placeholder.
+type sqlToken struct {
+	Text        string
+	Ident       bool
+	Placeholder int
+}
+
+var (
+	// comparisonOperators are the operators whose other operand is used to name a parameter.
+	comparisonOperators = []string{"=", "==", "<>", "!=", "<", ">", "<=", ">=", "LIKE", "ILIKE", "IN"}
+	// nonColumnKeywords are identifiers which never refer to a column.
+	nonColumnKeywords = []string{"NULL", "TRUE", "FALSE", "DEFAULT", "NOT", "AND", "OR", "AS", "SELECT", "WHERE", "SET"}
+)
+
+// tokenizeQuery splits the given query into identifiers, placeholders and operators. String literals are returned as
+// a single non-identifier token, comments are discarded.
+func tokenizeQuery(engine, query string) []sqlToken {
+	tokens := make([]sqlToken, 0)
+	positional := 0
+
+	for i := 0; i < len(query); {
+		c := query[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(query[i:], "--") || (engine == "mysql" && c == '#'):
+			idx := strings.IndexByte(query[i:], '\n')
+			if idx == -1 {
+				return tokens
+			}
+			i += idx + 1
+		case strings.HasPrefix(query[i:], "/*"):
+			i = skipBlockComment(query, i)
+		case c == '\'':
+			end := skipQuoted(query, i, engine == "mysql" || isEscapeStringPrefix(query, i))
+			tokens = append(tokens, sqlToken{Text: query[i:end]})
+			i = end
+		case c == '"' || c == '`':
+			end := skipQuoted(query, i, false)
+			tokens = append(tokens, sqlToken{Text: strings.Trim(query[i:end], string(c)), Ident: true})
+			i = end
+		case engine == "postgresql" && c == '$' && i+1 < len(query) && isDigit(query[i+1]):
+			end := i + 1
+			for end < len(query) && isDigit(query[end]) {
+				end++
+			}
+			number, _ := strconv.Atoi(query[i+1 : end])
+			tokens = append(tokens, sqlToken{Text: query[i:end], Placeholder: number})
+			i = end
+		case engine != "postgresql" && c == '?':
+			end := i + 1
+			for end < len(query) && isDigit(query[end]) {
+				end++
+			}
+			// sqlite allows the parameter number to be given explicitly, e.g. ?2
+			positional++
+			if end > i+1 {
+				positional, _ = strconv.Atoi(query[i+1 : end])
+			}
+			tokens = append(tokens, sqlToken{Text: query[i:end], Placeholder: positional})
+			i = end
+		case isIdentChar(c) && !isDigit(c):
+			end := i + 1
+			for end < len(query) && (isIdentChar(query[end]) || query[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, sqlToken{Text: query[i:end], Ident: true})
+			i = end
+		case strings.ContainsRune("<>=!~+-*/%|&^#@?:", rune(c)):
+			end := i + 1
+			for end < len(query) && strings.ContainsRune("<>=!~+-*/%|&^#@?:", rune(query[end])) &&
+				!strings.HasPrefix(query[end:], "--") && !strings.HasPrefix(query[end:], "/*") {
+				end++
+			}
+			tokens = append(tokens, sqlToken{Text: query[i:end]})
+			i = end
+		default:
+			tokens = append(tokens, sqlToken{Text: string(c)})
+			i++
+		}
+	}
+
+	return tokens
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// inferParamNames derives a name for each parameter of the given query from the context it is used in, e.g. the
+// column it is compared against or inserted into. Parameters which cannot be named are omitted from the result.
+func inferParamNames(engine, query string) map[int]string {
+	tokens := tokenizeQuery(engine, query)
+	names := insertParamNames(tokens)
+
+	for i, tok := range tokens {
+		if tok.Placeholder == 0 {
+			continue
+		}
+		if _, ok := names[tok.Placeholder]; ok {
+			continue
+		}
+
+		if name := contextParamName(tokens, i); name != "" {
+			names[tok.Placeholder] = name
+		}
+	}
+	return names
+}
+
+// contextParamName derives a name for the placeholder at the given index from the expression it is used within.
+func contextParamName(tokens []sqlToken, idx int) string {
+	start, end := idx, skipCast(tokens, idx)
+	// expand the operand to include any function calls or parentheses wrapping the placeholder, e.g. lower($1)
+	for start > 0 && tokens[start-1].Text == "(" && end+1 < len(tokens) && tokens[end+1].Text == ")" {
+		start--
+		end = skipCast(tokens, end+1)
+		if start > 0 && tokens[start-1].Ident && !isKeyword(tokens[start-1], "IN") {
+			start--
+		}
+	}
+
+	if start > 0 {
+		prev := tokens[start-1]
+		switch {
+		case isKeyword(prev, "LIMIT"):
+			return "limit"
+		case isKeyword(prev, "OFFSET"):
+			return "offset"
+		case isComparison(prev):
+			operandEnd := start - 2
+			if operandEnd >= 0 && isKeyword(tokens[operandEnd], "NOT") {
+				operandEnd--
+			}
+			if name := operandColumnBefore(tokens, operandEnd); name != "" {
+				return name
+			}
+		}
+	}
+
+	if end+2 < len(tokens) && isComparison(tokens[end+1]) {
+		return operandColumnAfter(tokens, end+2)
+	}
+	return ""
+}
+
+// skipCast returns the index of the final token of the casts applied to the operand ending at the given index, e.g.
+// "$1::text[]" or "CAST($1 AS text)".
+func skipCast(tokens []sqlToken, idx int) int {
+	for {
+		switch {
+		case idx+2 < len(tokens) && (tokens[idx+1].Text == "::" || isKeyword(tokens[idx+1], "AS")) && tokens[idx+2].Ident:
+			idx += 2
+		case idx+2 < len(tokens) && tokens[idx+1].Text == "[" && tokens[idx+2].Text == "]":
+			idx += 2
+		default:
+			return idx
+		}
+	}
+}
+
+// operandColumnBefore returns the column referenced by the operand ending at the given index.
+func operandColumnBefore(tokens []sqlToken, end int) string {
+	// strip casts applied to the operand, e.g. email::text
+	for end >= 2 && tokens[end].Ident && tokens[end-1].Text == "::" {
+		end -= 2
+	}
+	if end < 0 {
+		return ""
+	}
+
+	if tokens[end].Text == ")" {
+		depth := 0
+		for start := end; start >= 0; start-- {
+			switch tokens[start].Text {
+			case ")":
+				depth++
+			case "(":
+				depth--
+			}
+			if depth == 0 {
+				return firstColumn(tokens[start+1 : end])
+			}
+		}
+		return ""
+	}
+	return columnName(tokens[end])
+}
+
+// operandColumnAfter returns the column referenced by the operand starting at the given index.
+func operandColumnAfter(tokens []sqlToken, start int) string {
+	if start+1 < len(tokens) && tokens[start].Ident && tokens[start+1].Text == "(" {
+		depth := 0
+		for end := start + 1; end < len(tokens); end++ {
+			switch tokens[end].Text {
+			case "(":
+				depth++
+			case ")":
+				depth--
+			}
+			if depth == 0 {
+				return firstColumn(tokens[start+2 : end])
+			}
+		}
+		return ""
+	}
+	return columnName(tokens[start])
+}
+
+// firstColumn returns the first column referenced within the given tokens, ignoring function names.
+func firstColumn(tokens []sqlToken) string {
+	for i, tok := range tokens {
+		if i+1 < len(tokens) && tokens[i+1].Text == "(" {
+			continue
+		}
+		if i > 0 && tokens[i-1].Text == "::" {
+			continue
+		}
+		if name := columnName(tok); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// columnName returns the unqualified column name referenced by the given token, if it is a column reference.
+func columnName(tok sqlToken) string {
+	if !tok.Ident || slices.Contains(nonColumnKeywords, strings.ToUpper(tok.Text)) {
+		return ""
+	}
+	return tok.Text[strings.LastIndex(tok.Text, ".")+1:]
+}
+
+func isKeyword(tok sqlToken, keyword string) bool {
+	return tok.Ident && strings.EqualFold(tok.Text, keyword)
+}
+
+func isComparison(tok sqlToken) bool {
+	return slices.Contains(comparisonOperators, strings.ToUpper(tok.Text))
+}
+
+// insertParamNames names the parameters given as values of an INSERT statement after the column they are inserted
+// into, e.g. "INSERT INTO users (email, name) VALUES ($1, lower($2))".
+func insertParamNames(tokens []sqlToken) map[int]string {
+	names := make(map[int]string)
+
+	idx := slices.IndexFunc(tokens, func(tok sqlToken) bool { return isKeyword(tok, "INSERT") })
+	if idx == -1 || idx+3 >= len(tokens) || !isKeyword(tokens[idx+1], "INTO") || tokens[idx+3].Text != "(" {
+		return names
+	}
+
+	var columns []string
+	i := idx + 4
+	for ; i < len(tokens) && tokens[i].Text != ")"; i++ {
+		if tokens[i].Text != "," {
+			columns = append(columns, columnName(tokens[i]))
+		}
+	}
+	if i+1 >= len(tokens) || !isKeyword(tokens[i+1], "VALUES") {
+		return names
+	}
+
+	depth, column := 0, 0
+	for _, tok := range tokens[i+2:] {
+		switch {
+		case tok.Text == "(":
+			depth++
+			if depth == 1 {
+				column = 0
+			}
+		case tok.Text == ")":
+			depth--
+			if depth < 0 {
+				return names
+			}
+		case tok.Text == "," && depth == 1:
+			column++
+		case tok.Placeholder != 0 && depth >= 1 && column < len(columns):
+			if _, ok := names[tok.Placeholder]; !ok && columns[column] != "" {
+				names[tok.Placeholder] = columns[column]
+			}
+		case depth == 0 && tok.Text != ",":
+			// the end of the VALUES list, e.g. ON CONFLICT or RETURNING
+			return names
+		}
+	}
+	return names
+}
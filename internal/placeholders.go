@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// rewritePostgresPlaceholders converts the numbered placeholders in the given postgres query into JDBC "?"
+// placeholders, returning the parameter number referenced by each one in order. Any literal question marks, such as
+// those used by the JSONB ?, ?| and ?& operators, are escaped as "??" so that the driver does not treat them as
+// placeholders. String literals, quoted identifiers, comments and dollar-quoted bodies are copied unchanged.
+func rewritePostgresPlaceholders(query string) (string, []int, error) {
+	numbers := make([]int, 0)
+	rewritten, err := replacePostgresPlaceholders(query, true, func(number int) string {
+		numbers = append(numbers, number)
+		return "?"
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return rewritten, numbers, nil
+}
+
+// castPostgresPlaceholders appends an explicit cast to each numbered placeholder in the given postgres query which
+// references one of the given parameter numbers, e.g. "$1" becomes "$1::mood".
+func castPostgresPlaceholders(query string, casts map[int]string) (string, error) {
+	return replacePostgresPlaceholders(query, false, func(number int) string {
+		placeholder := "$" + strconv.Itoa(number)
+		if sqlType, ok := casts[number]; ok {
+			return placeholder + "::" + sqlType
+		}
+		return placeholder
+	})
+}
+
+// replacePostgresPlaceholders replaces each numbered placeholder in the given postgres query with the result of the
+// given function. String literals, quoted identifiers, comments and dollar-quoted bodies are copied unchanged, and
+// any literal question marks are escaped as "??" if requested.
+func replacePostgresPlaceholders(query string, escapeQuestionMarks bool, replace func(number int) string) (string, error) {
+	var sb strings.Builder
+	sb.Grow(len(query))
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		end := i + 1
+
+		switch {
+		case c == '\'':
+			end = skipQuoted(query, i, isEscapeStringPrefix(query, i))
+		case c == '"':
+			end = skipQuoted(query, i, false)
+		case strings.HasPrefix(query[i:], "--"):
+			end = len(query)
+			if idx := strings.IndexByte(query[i:], '\n'); idx != -1 {
+				end = i + idx + 1
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			end = skipBlockComment(query, i)
+		case c == '$' && (i == 0 || !isIdentChar(query[i-1])):
+			digits := i + 1
+			for digits < len(query) && query[digits] >= '0' && query[digits] <= '9' {
+				digits++
+			}
+
+			if digits > i+1 {
+				number, err := strconv.Atoi(query[i+1 : digits])
+				if err != nil {
+					return "", fmt.Errorf("invalid placeholder in query: %s", query[i:digits])
+				}
+				sb.WriteString(replace(number))
+				i = digits
+				continue
+			}
+
+			if tag, ok := dollarQuoteTag(query, i); ok {
+				end = len(query)
+				if idx := strings.Index(query[i+len(tag):], tag); idx != -1 {
+					end = i + len(tag) + idx + len(tag)
+				}
+			}
+		case c == '?' && escapeQuestionMarks:
+			sb.WriteString("??")
+			i = end
+			continue
+		}
+
+		sb.WriteString(query[i:end])
+		i = end
+	}
+
+	return sb.String(), nil
+}
+
+// postgresTypeName returns the given (possibly schema qualified) type name as it must be written in a postgres query,
+// quoting any part which would otherwise be folded to lower case.
+func postgresTypeName(sqlType string) string {
+	parts := strings.Split(sqlType, ".")
+	for i, part := range parts {
+		if strings.IndexFunc(part, func(r rune) bool { return !(r == '_' || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')) }) != -1 {
+			parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// isIdentChar returns whether the given byte may appear within an unquoted postgres identifier. Bytes of multibyte
+// UTF-8 characters are always considered part of an identifier.
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// isEscapeStringPrefix returns whether the string literal starting at the given index is an escape string constant
+// (e.g. E'foo\'s'), in which backslashes escape the following character.
+func isEscapeStringPrefix(query string, start int) bool {
+	if start == 0 || (query[start-1] != 'e' && query[start-1] != 'E') {
+		return false
+	}
+	return start == 1 || !isIdentChar(query[start-2])
+}
+
+// skipQuoted returns the index immediately after the quoted string or identifier starting at the given index.
+func skipQuoted(query string, start int, backslashEscapes bool) int {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		switch {
+		case backslashEscapes && query[i] == '\\':
+			i++
+		case query[i] == quote:
+			// doubled quotes are an escaped quote character, not the end of the string
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+// skipBlockComment returns the index immediately after the (possibly nested) block comment starting at the given index.
+func skipBlockComment(query string, start int) int {
+	depth := 0
+	for i := start; i < len(query)-1; i++ {
+		switch query[i : i+2] {
+		case "/*":
+			depth++
+			i++
+		case "*/":
+			depth--
+			i++
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(query)
+}
+
+// dollarQuoteTag returns the opening tag of the dollar-quoted string starting at the given index, e.g. "$$" or
+// "$body$", if one exists.
+func dollarQuoteTag(query string, start int) (string, bool) {
+	for i := start + 1; i < len(query); i++ {
+		c := query[i]
+		if c == '$' {
+			return query[start : i+1], true
+		}
+		if !isIdentChar(c) || (i == start+1 && c >= '0' && c <= '9') {
+			return "", false
+		}
+	}
+	return "", false
+}
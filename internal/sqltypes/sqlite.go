@@ -0,0 +1,53 @@
+package sqltypes
+
+import (
+	"strings"
+
+	"github.com/sqlc-dev/plugin-sdk-go/plugin"
+	"github.com/sqlc-dev/plugin-sdk-go/sdk"
+)
+
+func SqliteTypeToJavaType(identifier *plugin.Identifier) (string, error) {
+	colType := strings.ToLower(sdk.DataType(identifier))
+	// strip any size or precision arguments, e.g. VARCHAR(255) or DECIMAL(10, 2)
+	if idx := strings.Index(colType, "("); idx != -1 {
+		colType = colType[:idx]
+	}
+	colType = strings.TrimSpace(colType)
+
+	// common declared type names first, as some of these would otherwise be given NUMERIC affinity
+	switch colType {
+	case "boolean", "bool":
+		return "Boolean", nil
+	case "date":
+		return "java.time.LocalDate", nil
+	case "time":
+		return "java.time.LocalTime", nil
+	case "datetime", "timestamp":
+		return "java.time.LocalDateTime", nil
+	case "real", "double", "double precision", "doubleprecision", "float":
+		return "Double", nil
+	case "decimal", "numeric":
+		return "java.math.BigDecimal", nil
+	case "json", "jsonb":
+		return "String", nil
+	case "", "any":
+		// columns declared without a type (or as ANY in strict tables) can hold a value of any storage class
+		return "Object", nil
+	}
+
+	// fall back to the SQLite type affinity rules - https://www.sqlite.org/datatype3.html#determination_of_column_affinity
+	switch {
+	case strings.Contains(colType, "int"):
+		// INTEGER affinity values are stored as up to 8 byte signed integers
+		return "Long", nil
+	case strings.Contains(colType, "char"), strings.Contains(colType, "clob"), strings.Contains(colType, "text"):
+		return "String", nil
+	case strings.Contains(colType, "blob"):
+		return "byte[]", nil
+	case strings.Contains(colType, "real"), strings.Contains(colType, "floa"), strings.Contains(colType, "doub"):
+		return "Double", nil
+	default:
+		return "java.math.BigDecimal", nil
+	}
+}
@@ -0,0 +1 @@
+package io.github.tandemdude.sgj.spring;
@@ -0,0 +1,3 @@
+package io.github.tandemdude.sgj.types;
+
+public record Payload(String json) {}
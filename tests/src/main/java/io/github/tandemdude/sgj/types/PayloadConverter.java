@@ -0,0 +1,27 @@
+package io.github.tandemdude.sgj.types;
+
+import org.postgresql.util.PGobject;
+
+import java.sql.SQLException;
+
+public final class PayloadConverter {
+    private PayloadConverter() {}
+
+    public static Payload fromDatabase(Object value) {
+        if (value instanceof PGobject) {
+            return new Payload(((PGobject) value).getValue());
+        }
+        return new Payload(value.toString());
+    }
+
+    public static Object toDatabase(Payload value) {
+        var obj = new PGobject();
+        obj.setType("jsonb");
+        try {
+            obj.setValue(value.json());
+        } catch (SQLException e) {
+            throw new IllegalArgumentException(e);
+        }
+        return obj;
+    }
+}
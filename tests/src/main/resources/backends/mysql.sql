@@ -0,0 +1,25 @@
+-- name: CreateAuthor :execresult
+INSERT INTO authors (name) VALUES (?);
+
+-- name: GetAuthor :one
+SELECT * FROM authors WHERE author_id = ?;
+
+-- name: CreateBook :execresult
+INSERT INTO books (
+    author_id,
+    isbn,
+    book_type,
+    title,
+    yr,
+    available,
+    tags
+) VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetBook :one
+SELECT * FROM books WHERE book_id = ?;
+
+-- name: CreateEnumRow :execresult
+INSERT INTO nullable_enum_test (enum_field) VALUES (?);
+
+-- name: GetEnumRow :one
+SELECT * FROM nullable_enum_test WHERE t_id = ?;
@@ -0,0 +1,44 @@
+-- name: CreateUser :exec
+INSERT INTO users(user_id, username, email)
+VALUES ($1, $2, $3);
+
+-- name: GetUser :one
+SELECT user_id, username, email FROM users WHERE user_id = $1;
+
+-- name: ListUsers :many
+-- @stream
+SELECT user_id, username, email FROM users ORDER BY username;
+
+-- name: DeleteUser :execrows
+DELETE FROM users WHERE user_id = $1;
+
+-- name: CreateTokens :copyfrom
+INSERT INTO tokens(user_id, token, expiry) VALUES ($1, $2, $3);
+
+-- name: GetToken :one
+SELECT * FROM tokens WHERE token = $1;
+
+-- name: UpsertUsernames :batchexec
+UPDATE users SET username = $2 WHERE user_id = $1;
+
+-- name: CreateTokensReturningId :batchone
+INSERT INTO tokens(user_id, token, expiry)
+VALUES ($1, $2, $3)
+RETURNING token_id;
+
+-- name: CreatePerson :exec
+INSERT INTO person(name, current_mood, next_mood) VALUES ($1, $2, $3);
+
+-- name: GetPerson :one
+SELECT * FROM person WHERE name = $1;
+
+-- name: ListPeopleByMood :many
+SELECT name FROM person WHERE current_mood = ANY($1::mood[]) ORDER BY name;
+
+-- name: CreateMessage :one
+INSERT INTO messages(chat_id, user_id, content, attachments)
+VALUES ($1, $2, $3, $4)
+RETURNING message_id;
+
+-- name: GetMessageAttachments :one
+SELECT attachments FROM messages WHERE message_id = $1;
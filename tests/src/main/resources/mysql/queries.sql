@@ -57,4 +57,16 @@ WHERE yr < ? AND author_id = ?;
 INSERT INTO nullable_enum_test (enum_field) VALUES (?);
 
 -- name: GetEnumRow :one
-SELECT * FROM nullable_enum_test WHERE t_id = ?;
\ No newline at end of file
+SELECT * FROM nullable_enum_test WHERE t_id = ?;
+
+-- name: CreateAuthors :copyfrom
+INSERT INTO authors (name) VALUES (?);
+
+-- name: ListAuthors :many
+SELECT * FROM authors ORDER BY author_id;
+
+-- name: ListAuthorsByIds :many
+SELECT * FROM authors WHERE author_id IN (sqlc.slice('ids')) ORDER BY author_id;
+
+-- name: GetAuthorsByIds :batchone
+SELECT * FROM authors WHERE author_id = ?;
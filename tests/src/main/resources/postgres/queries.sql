@@ -55,3 +55,40 @@ INSERT INTO person(name, current_mood, next_mood) VALUES ($1, $2, $3);
 
 -- name: GetPerson :one
 SELECT * FROM person WHERE name = $1;
+
+-- name: CreateTokens :copyfrom
+INSERT INTO tokens(user_id, token, expiry) VALUES ($1, $2, $3);
+
+-- name: ListTokens :many
+-- @stream
+SELECT * FROM tokens ORDER BY token_id;
+
+-- name: CreateSetting :exec
+INSERT INTO settings(name, payload, extra) VALUES ($1, $2, $3);
+
+-- name: GetSetting :one
+SELECT * FROM settings WHERE name = $1;
+
+-- name: FindUsersByName :many
+SELECT * FROM users WHERE username = $2 OR email = $2 OR user_id = $1 ORDER BY username;
+
+-- name: ListSettingsWithKey :many
+SELECT name FROM settings WHERE payload ? sqlc.arg(key)::text ORDER BY name;
+
+-- name: UpsertUsernames :batchexec
+UPDATE users SET username = $2 WHERE user_id = $1;
+
+-- name: CreateTokensReturningId :batchone
+INSERT INTO tokens(user_id, token, expiry)
+VALUES ($1, $2, $3)
+RETURNING token_id;
+
+-- name: RenewTokensForUsers :batchmany
+UPDATE tokens SET expiry = $2 WHERE user_id = $1
+RETURNING token;
+
+-- name: ListUsersWithTokens :many
+SELECT sqlc.embed(users), sqlc.embed(tokens)
+FROM users
+LEFT JOIN tokens ON tokens.user_id = users.user_id
+ORDER BY users.username;
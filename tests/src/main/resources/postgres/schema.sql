@@ -45,3 +45,10 @@ CREATE TABLE person (
     current_mood mood NOT NULL,
     next_mood mood DEFAULT NULL
 );
+
+-- table for testing type overrides
+CREATE TABLE settings (
+    name    TEXT PRIMARY KEY,
+    payload JSONB NOT NULL,
+    extra   JSONB DEFAULT NULL
+);
@@ -0,0 +1,26 @@
+-- name: CreateAuthor :execresult
+INSERT INTO authors (name, bio) VALUES (?, ?);
+
+-- name: GetAuthor :one
+SELECT * FROM authors WHERE author_id = ?;
+
+-- name: ListAuthors :many
+SELECT * FROM authors ORDER BY author_id;
+
+-- name: CreateBook :one
+INSERT INTO books (author_id, title, price, rating, available, published, cover)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING book_id;
+
+-- name: GetBook :one
+SELECT * FROM books WHERE book_id = ?;
+
+-- name: DeleteBooksByAuthor :execrows
+DELETE FROM books WHERE author_id = ?;
+
+-- name: ListBooksPublishedBetween :many
+SELECT books.book_id, books.author_id, authors.author_id, books.title
+FROM books
+JOIN authors ON authors.author_id = books.author_id
+WHERE books.published >= ? AND books.published <= ?
+ORDER BY books.book_id;
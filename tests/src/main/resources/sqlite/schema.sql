@@ -0,0 +1,17 @@
+CREATE TABLE authors (
+    author_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name      TEXT NOT NULL,
+    bio       TEXT
+);
+
+CREATE TABLE books (
+    book_id    INTEGER PRIMARY KEY AUTOINCREMENT,
+    author_id  INTEGER NOT NULL,
+    title      VARCHAR(255) NOT NULL,
+    price      DECIMAL(10, 2) NOT NULL,
+    rating     REAL,
+    available  BOOLEAN NOT NULL DEFAULT 1,
+    published  DATE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    cover      BLOB
+);
@@ -0,0 +1,123 @@
+package io.github.tandemdude.sgj.async;
+
+import org.junit.jupiter.api.AfterEach;
+import org.junit.jupiter.api.BeforeEach;
+import org.junit.jupiter.api.DisplayName;
+import org.junit.jupiter.api.Test;
+import org.postgresql.ds.PGSimpleDataSource;
+import org.testcontainers.containers.PostgreSQLContainer;
+import org.testcontainers.junit.jupiter.Container;
+import org.testcontainers.junit.jupiter.Testcontainers;
+
+import java.sql.SQLException;
+import java.time.LocalDateTime;
+import java.util.List;
+import java.util.Optional;
+import java.util.UUID;
+import java.util.concurrent.CompletableFuture;
+import java.util.concurrent.CompletionException;
+import java.util.concurrent.ExecutorService;
+import java.util.concurrent.Executors;
+import java.util.stream.IntStream;
+
+import static org.assertj.core.api.Assertions.assertThat;
+import static org.assertj.core.api.Assertions.assertThatThrownBy;
+
+@Testcontainers
+public class TestQueries {
+    @Container
+    private final PostgreSQLContainer<?> postgres = new PostgreSQLContainer<>("postgres:latest")
+        .withInitScript("postgres/schema.sql");
+
+    private ExecutorService executor;
+    private Queries q;
+
+    @BeforeEach
+    void setUp() {
+        var dataSource = new PGSimpleDataSource();
+        dataSource.setUrl(postgres.getJdbcUrl());
+        dataSource.setUser(postgres.getUsername());
+        dataSource.setPassword(postgres.getPassword());
+
+        executor = Executors.newFixedThreadPool(4);
+        q = new Queries(dataSource);
+    }
+
+    @AfterEach
+    void tearDown() {
+        executor.shutdownNow();
+    }
+
+    @Test
+    @DisplayName("GetUserAsync returns the created user")
+    void getUserAsyncReturnsCreatedUser() {
+        assertThat(q.getUserAsync(UUID.randomUUID(), executor).join()).isEmpty();
+
+        var uid = UUID.randomUUID();
+        q.createUserAsync(uid, "foo", "foo@example.com", executor).join();
+
+        var found = q.getUserAsync(uid, executor).join();
+        assertThat(found).isPresent();
+        assertThat(found.get().username()).isEqualTo("foo");
+        assertThat(found.get().email()).isEqualTo("foo@example.com");
+    }
+
+    @Test
+    @DisplayName("async calls can run concurrently when backed by a DataSource")
+    void asyncCallsRunConcurrently() {
+        var futures = IntStream.range(0, 10)
+            .mapToObj(i -> q.createUserAsync(UUID.randomUUID(), "user" + i, "user" + i + "@example.com", executor))
+            .toArray(CompletableFuture[]::new);
+        CompletableFuture.allOf(futures).join();
+
+        assertThat(q.listUsersAsync(executor).join()).hasSize(10);
+    }
+
+    @Test
+    @DisplayName("DeleteUserAsync returns the number of rows deleted")
+    void deleteUserAsyncReturnsRowsDeleted() {
+        var uid = UUID.randomUUID();
+        q.createUserAsync(uid, "foo", "foo@example.com", executor).join();
+
+        assertThat(q.deleteUserAsync(uid, executor).join()).isEqualTo(1);
+        assertThat(q.deleteUserAsync(uid, executor).join()).isEqualTo(0);
+    }
+
+    @Test
+    @DisplayName("copyfrom and batch queries return a result for each row")
+    void copyFromAndBatchQueriesReturnAResultForEachRow() {
+        var uid = UUID.randomUUID();
+        q.createUserAsync(uid, "foo", "foo@example.com", executor).join();
+
+        var expiry = LocalDateTime.of(2030, 1, 1, 12, 0);
+        var inserted = q.createTokensAsync(List.of(
+            new Queries.CreateTokensParams(uid, "a", expiry),
+            new Queries.CreateTokensParams(uid, "b", expiry)
+        ), executor).join();
+        assertThat(inserted).isEqualTo(2L);
+
+        var counts = q.upsertUsernamesAsync(List.of(
+            new Queries.UpsertUsernamesParams(uid, "foo2"),
+            new Queries.UpsertUsernamesParams(UUID.randomUUID(), "bar")
+        ), executor).join();
+        assertThat(counts).containsExactly(1, 0);
+
+        var ids = q.createTokensReturningIdAsync(List.of(
+            new Queries.CreateTokensReturningIdParams(uid, "c", expiry),
+            new Queries.CreateTokensReturningIdParams(uid, "d", expiry)
+        ), executor).join();
+        assertThat(ids).hasSize(2).allMatch(Optional::isPresent);
+        assertThat(q.getTokenAsync("d", executor).join()).map(Queries.GetTokenRow::tokenId).isEqualTo(ids.get(1));
+    }
+
+    @Test
+    @DisplayName("SQLExceptions complete the future exceptionally")
+    void sqlExceptionsCompleteExceptionally() {
+        var uid = UUID.randomUUID();
+        q.createUserAsync(uid, "foo", "foo@example.com", executor).join();
+
+        assertThatThrownBy(() -> q.createUserAsync(uid, "foo", "foo@example.com", executor).join())
+            .isInstanceOf(CompletionException.class)
+            .hasCauseInstanceOf(SQLException.class);
+    }
+}
@@ -0,0 +1,92 @@
+package io.github.tandemdude.sgj.builders;
+
+import io.github.tandemdude.sgj.builders.models.Token;
+import io.github.tandemdude.sgj.builders.models.User;
+import org.junit.jupiter.api.DisplayName;
+import org.junit.jupiter.api.Test;
+import org.testcontainers.containers.PostgreSQLContainer;
+import org.testcontainers.junit.jupiter.Container;
+import org.testcontainers.junit.jupiter.Testcontainers;
+
+import java.sql.Connection;
+import java.sql.DriverManager;
+import java.sql.SQLException;
+import java.time.LocalDateTime;
+import java.util.List;
+import java.util.UUID;
+
+import static org.assertj.core.api.Assertions.assertThat;
+import static org.assertj.core.api.Assertions.assertThatThrownBy;
+
+@Testcontainers
+public class TestQueries {
+    @Container
+    private final PostgreSQLContainer<?> postgres = new PostgreSQLContainer<>("postgres:latest")
+        .withInitScript("postgres/schema.sql");
+
+    Connection getConn() throws SQLException {
+        var conn = DriverManager.getConnection(postgres.getJdbcUrl(), postgres.getUsername(), postgres.getPassword());
+        conn.setAutoCommit(true);
+        return conn;
+    }
+
+    @Test
+    @DisplayName("withers and toBuilder copy a returned row")
+    void withersAndToBuilderCopyReturnedRow() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            var uid = UUID.randomUUID();
+            q.createUser(uid, "foo", "foo@example.com");
+
+            var found = q.getUser(uid).orElseThrow();
+            var renamed = found.withUsername("bar");
+            assertThat(renamed).isEqualTo(new Queries.GetUserRow(uid, "bar", "foo@example.com"));
+            assertThat(found.username()).isEqualTo("foo");
+
+            assertThat(found.toBuilder().email("bar@example.com").build())
+                .isEqualTo(new Queries.GetUserRow(uid, "foo", "bar@example.com"));
+            assertThat(found.toBuilder().build()).isEqualTo(found);
+        }
+    }
+
+    @Test
+    @DisplayName("builders construct models matching the rows returned")
+    void buildersConstructModels() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            var uid = UUID.randomUUID();
+            q.createUser(uid, "foo", "foo@example.com");
+
+            var expiry = LocalDateTime.of(2030, 1, 1, 12, 0);
+            var ids = q.createTokensReturningId(List.of(new Queries.CreateTokensReturningIdParams(uid, "a", expiry)));
+            assertThat(ids).hasSize(1);
+            var tokenId = ids.get(0).orElseThrow();
+
+            var expected = new Token.Builder()
+                .tokenId(tokenId)
+                .userId(uid)
+                .token("a")
+                .expiry(expiry)
+                .build();
+            assertThat(q.getToken("a")).contains(expected);
+            assertThat(expected.withToken("b").token()).isEqualTo("b");
+        }
+    }
+
+    @Test
+    @DisplayName("build rejects unset non-null fields")
+    void buildRejectsUnsetFields() {
+        assertThatThrownBy(() -> new Token.Builder().userId(UUID.randomUUID()).token("a").expiry(LocalDateTime.now()).build())
+            .isInstanceOf(NullPointerException.class)
+            .hasMessage("tokenId");
+
+        var user = new User.Builder()
+            .userId(UUID.randomUUID())
+            .username("foo")
+            .email("foo@example.com")
+            .build();
+        assertThat(user.createdAt()).isNull();
+    }
+}
@@ -12,6 +12,7 @@ import java.sql.Connection;
 import java.sql.DriverManager;
 import java.sql.SQLException;
 import java.time.LocalDateTime;
+import java.util.List;
 
 import static org.assertj.core.api.Assertions.assertThat;
 
@@ -60,4 +61,76 @@ public class TestQueries {
             assertThat(foundRow2.get().enumField()).isNull();
         }
     }
+
+    @Test
+    @DisplayName("CreateAuthors inserts all rows")
+    void createAuthorsInsertsAllRows() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            var inserted = q.createAuthors(List.of(
+                new Queries.CreateAuthorsParams("foo"),
+                new Queries.CreateAuthorsParams("bar")
+            ));
+            assertThat(inserted).isEqualTo(2);
+
+            var found = q.listAuthors();
+            assertThat(found).hasSize(2);
+            assertThat(found.get(0).name()).isEqualTo("foo");
+            assertThat(found.get(1).name()).isEqualTo("bar");
+        }
+    }
+
+    @Test
+    @DisplayName("queries class can be used through the generated interface")
+    void queriesClassCanBeUsedThroughTheGeneratedInterface() throws Exception {
+        try (var conn = getConn()) {
+            Querier q = new Queries(conn);
+
+            var authorId = q.createAuthor("foo");
+            var found = q.getAuthor((int) authorId);
+            assertThat(found).isPresent();
+            assertThat(found.get().name()).isEqualTo("foo");
+        }
+    }
+
+    @Test
+    @DisplayName("ListAuthorsByIds expands slice parameters")
+    void listAuthorsByIdsExpandsSliceParameters() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            var first = (int) q.createAuthor("foo");
+            q.createAuthor("bar");
+            var third = (int) q.createAuthor("baz");
+
+            var found = q.listAuthorsByIds(List.of(first, third));
+            assertThat(found).hasSize(2);
+            assertThat(found.get(0).name()).isEqualTo("foo");
+            assertThat(found.get(1).name()).isEqualTo("baz");
+
+            assertThat(q.listAuthorsByIds(List.of())).isEmpty();
+        }
+    }
+
+    @Test
+    @DisplayName("GetAuthorsByIds executes each batch element separately")
+    void getAuthorsByIdsExecutesEachElementSeparately() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            var first = (int) q.createAuthor("foo");
+            var second = (int) q.createAuthor("bar");
+
+            var found = q.getAuthorsByIds(List.of(
+                new Queries.GetAuthorsByIdsParams(second),
+                new Queries.GetAuthorsByIdsParams(-1),
+                new Queries.GetAuthorsByIdsParams(first)
+            ));
+            assertThat(found).hasSize(3);
+            assertThat(found.get(0)).map(Queries.GetAuthorsByIdsRow::name).contains("bar");
+            assertThat(found.get(1)).isEmpty();
+            assertThat(found.get(2)).map(Queries.GetAuthorsByIdsRow::name).contains("foo");
+        }
+    }
 }
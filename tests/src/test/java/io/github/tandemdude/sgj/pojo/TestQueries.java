@@ -0,0 +1,99 @@
+package io.github.tandemdude.sgj.pojo;
+
+import io.github.tandemdude.sgj.pojo.models.Token;
+import org.junit.jupiter.api.DisplayName;
+import org.junit.jupiter.api.Test;
+import org.testcontainers.containers.PostgreSQLContainer;
+import org.testcontainers.junit.jupiter.Container;
+import org.testcontainers.junit.jupiter.Testcontainers;
+
+import java.sql.Connection;
+import java.sql.DriverManager;
+import java.sql.SQLException;
+import java.time.LocalDateTime;
+import java.util.List;
+import java.util.UUID;
+
+import static org.assertj.core.api.Assertions.assertThat;
+
+@Testcontainers
+public class TestQueries {
+    @Container
+    private final PostgreSQLContainer<?> postgres = new PostgreSQLContainer<>("postgres:latest")
+        .withInitScript("postgres/schema.sql");
+
+    Connection getConn() throws SQLException {
+        var conn = DriverManager.getConnection(postgres.getJdbcUrl(), postgres.getUsername(), postgres.getPassword());
+        conn.setAutoCommit(true);
+        return conn;
+    }
+
+    @Test
+    @DisplayName("row classes expose columns through getters")
+    void rowClassesExposeGetters() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            var uid = UUID.randomUUID();
+            q.createUser(uid, "foo", "foo@example.com");
+
+            var found = q.getUser(uid);
+            assertThat(found).isPresent();
+            assertThat(found.get().getUserId()).isEqualTo(uid);
+            assertThat(found.get().getUsername()).isEqualTo("foo");
+            assertThat(found.get().getEmail()).isEqualTo("foo@example.com");
+
+            try (var users = q.listUsersStream()) {
+                assertThat(users.map(Queries.ListUsersRow::getUsername)).containsExactly("foo");
+            }
+        }
+    }
+
+    @Test
+    @DisplayName("row classes implement value equality")
+    void rowClassesImplementValueEquality() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            var uid = UUID.randomUUID();
+            q.createUser(uid, "foo", "foo@example.com");
+
+            var first = q.getUser(uid).orElseThrow();
+            var second = q.getUser(uid).orElseThrow();
+            assertThat(first).isNotSameAs(second).isEqualTo(second).hasSameHashCodeAs(second);
+            assertThat(first.toString()).contains("foo@example.com");
+            assertThat(first).isEqualTo(new Queries.GetUserRow(uid, "foo", "foo@example.com"));
+        }
+    }
+
+    @Test
+    @DisplayName("params classes are bound using their getters")
+    void paramsClassesAreBound() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            var uid = UUID.randomUUID();
+            q.createUser(uid, "foo", "foo@example.com");
+
+            var expiry = LocalDateTime.of(2030, 1, 1, 12, 0);
+            var inserted = q.createTokens(List.of(
+                new Queries.CreateTokensParams(uid, "a", expiry),
+                new Queries.CreateTokensParams(uid, "b", expiry)
+            ));
+            assertThat(inserted).isEqualTo(2L);
+
+            var counts = q.upsertUsernames(List.of(new Queries.UpsertUsernamesParams(uid, "foo2")));
+            assertThat(counts).containsExactly(1);
+            assertThat(q.getUser(uid)).map(Queries.GetUserRow::getUsername).contains("foo2");
+
+            var ids = q.createTokensReturningId(List.of(new Queries.CreateTokensReturningIdParams(uid, "c", expiry)));
+            assertThat(ids).hasSize(1);
+            assertThat(ids.get(0)).isPresent();
+
+            var token = q.getToken("c");
+            assertThat(token).isPresent();
+            assertThat(token.get()).isEqualTo(new Token(ids.get(0).get(), uid, "c", expiry));
+            assertThat(token.get().getTokenId()).isEqualTo(ids.get(0).get());
+        }
+    }
+}
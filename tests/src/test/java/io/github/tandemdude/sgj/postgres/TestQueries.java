@@ -1,8 +1,10 @@
 package io.github.tandemdude.sgj.postgres;
 
 import io.github.tandemdude.sgj.postgres.enums.Mood;
+import io.github.tandemdude.sgj.types.Payload;
 import org.junit.jupiter.api.Test;
 import org.junit.jupiter.api.DisplayName;
+import org.postgresql.ds.PGSimpleDataSource;
 import org.testcontainers.containers.PostgreSQLContainer;
 import org.testcontainers.junit.jupiter.Container;
 import org.testcontainers.junit.jupiter.Testcontainers;
@@ -13,6 +15,7 @@ import java.sql.DriverManager;
 import java.sql.SQLException;
 import java.time.LocalDateTime;
 import java.util.List;
+import java.util.Optional;
 import java.util.UUID;
 
 import static org.assertj.core.api.Assertions.assertThat;
@@ -189,4 +192,221 @@ public class TestQueries {
             assertThat(p2.get().nextMood()).isEqualTo(Mood.OK);
         }
     }
+
+    @Test
+    @DisplayName("CreateTokens copies all rows")
+    void createTokensCopiesAllRows() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            var userUid = UUID.randomUUID();
+            var expiry = LocalDateTime.of(2030, 1, 1, 12, 0);
+            var inserted = q.createTokens(List.of(
+                new Queries.CreateTokensParams(userUid, "foo", expiry),
+                new Queries.CreateTokensParams(userUid, "bar \"baz\", bork", expiry)
+            ));
+            assertThat(inserted).isEqualTo(2);
+
+            var found = q.listTokens();
+            assertThat(found).hasSize(2);
+            assertThat(found.get(0).token()).isEqualTo("foo");
+            assertThat(found.get(1).token()).isEqualTo("bar \"baz\", bork");
+            assertThat(found.get(1).expiry()).isEqualTo(expiry);
+        }
+    }
+
+    @Test
+    @DisplayName("overridden types are converted using the configured converter")
+    void overriddenTypesAreConvertedUsingTheConfiguredConverter() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            q.createSetting("foo", new Payload("{\"bar\": 1}"), null);
+
+            var found = q.getSetting("foo");
+            assertThat(found).isPresent();
+            assertThat(found.get().payload().json()).isEqualTo("{\"bar\": 1}");
+            assertThat(found.get().extra()).isNull();
+        }
+    }
+
+    @Test
+    @DisplayName("repeated and reordered placeholders are bound correctly")
+    void repeatedAndReorderedPlaceholdersAreBoundCorrectly() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            var fooUid = UUID.randomUUID();
+            var barUid = UUID.randomUUID();
+            q.createUser(fooUid, "foo", "foo@example.com");
+            q.createUser(barUid, "bar", "bar@example.com");
+            q.createUser(UUID.randomUUID(), "baz", "baz@example.com");
+
+            var found = q.findUsersByName(barUid, "foo@example.com");
+            assertThat(found).hasSize(2);
+            assertThat(found.get(0).userId()).isEqualTo(barUid);
+            assertThat(found.get(1).userId()).isEqualTo(fooUid);
+        }
+    }
+
+    @Test
+    @DisplayName("JSONB question mark operators are not treated as placeholders")
+    void jsonbQuestionMarkOperatorsAreNotTreatedAsPlaceholders() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            q.createSetting("foo", new Payload("{\"beta\": true}"), null);
+            q.createSetting("bar", new Payload("{\"alpha\": true}"), null);
+
+            assertThat(q.listSettingsWithKey("beta")).containsExactly("foo");
+            assertThat(q.listSettingsWithKey("gamma")).isEmpty();
+        }
+    }
+
+    @Test
+    @DisplayName("ListTokensStream streams rows and restores autocommit when closed")
+    void listTokensStreamStreamsRowsAndRestoresAutoCommit() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            var userUid = UUID.randomUUID();
+            var expiry = LocalDateTime.of(2030, 1, 1, 12, 0);
+            q.createTokens(List.of(
+                new Queries.CreateTokensParams(userUid, "foo", expiry),
+                new Queries.CreateTokensParams(userUid, "bar", expiry)
+            ));
+
+            try (var tokens = q.listTokensStream()) {
+                assertThat(conn.getAutoCommit()).isFalse();
+                assertThat(tokens.map(Queries.ListTokensRow::token)).containsExactly("foo", "bar");
+            }
+            assertThat(conn.getAutoCommit()).isTrue();
+        }
+    }
+
+    @Test
+    @DisplayName("batch queries return a result for each set of params")
+    void batchQueriesReturnAResultForEachSetOfParams() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            var fooUid = UUID.randomUUID();
+            var barUid = UUID.randomUUID();
+            q.createUser(fooUid, "foo", "foo@example.com");
+            q.createUser(barUid, "bar", "bar@example.com");
+
+            var counts = q.upsertUsernames(List.of(
+                new Queries.UpsertUsernamesParams(fooUid, "foo2"),
+                new Queries.UpsertUsernamesParams(UUID.randomUUID(), "baz")
+            ));
+            assertThat(counts).containsExactly(1, 0);
+            assertThat(q.getUser(fooUid).get().username()).isEqualTo("foo2");
+
+            var expiry = LocalDateTime.of(2030, 1, 1, 12, 0);
+            var ids = q.createTokensReturningId(List.of(
+                new Queries.CreateTokensReturningIdParams(fooUid, "a", expiry),
+                new Queries.CreateTokensReturningIdParams(fooUid, "b", expiry),
+                new Queries.CreateTokensReturningIdParams(barUid, "c", expiry)
+            ));
+            assertThat(ids).hasSize(3).allMatch(Optional::isPresent);
+
+            var renewed = expiry.plusDays(30);
+            var tokens = q.renewTokensForUsers(List.of(
+                new Queries.RenewTokensForUsersParams(barUid, renewed),
+                new Queries.RenewTokensForUsersParams(UUID.randomUUID(), renewed),
+                new Queries.RenewTokensForUsersParams(fooUid, renewed)
+            ));
+            assertThat(tokens).hasSize(3);
+            assertThat(tokens.get(0)).containsExactly("c");
+            assertThat(tokens.get(1)).isEmpty();
+            assertThat(tokens.get(2)).containsExactlyInAnyOrder("a", "b");
+        }
+    }
+
+    @Test
+    @DisplayName("outer joined embedded objects are null when there is no matching row")
+    void outerJoinedEmbeddedObjectsAreNullWhenThereIsNoMatchingRow() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            var fooUid = UUID.randomUUID();
+            q.createUser(fooUid, "foo", "foo@example.com");
+            q.createUser(UUID.randomUUID(), "bar", "bar@example.com");
+            q.createToken(fooUid, "token", LocalDateTime.now());
+
+            var found = q.listUsersWithTokens();
+            assertThat(found).hasSize(2);
+            assertThat(found.get(0).user().username()).isEqualTo("bar");
+            assertThat(found.get(0).token()).isNull();
+            assertThat(found.get(1).user().username()).isEqualTo("foo");
+            assertThat(found.get(1).token()).isNotNull();
+            assertThat(found.get(1).token().token()).isEqualTo("token");
+        }
+    }
+
+    @Test
+    @DisplayName("queries constructed using a data source borrow a connection per call")
+    void queriesConstructedUsingDataSource() throws Exception {
+        var dataSource = new PGSimpleDataSource();
+        dataSource.setUrl(postgres.getJdbcUrl());
+        dataSource.setUser(postgres.getUsername());
+        dataSource.setPassword(postgres.getPassword());
+
+        var q = new Queries(dataSource);
+        var uid = UUID.randomUUID();
+        q.createUser(uid, "foo", "bar");
+
+        var found = q.getUser(uid);
+        assertThat(found).isPresent();
+        assertThat(found.get().username()).isEqualTo("foo");
+        try (var tokens = q.listTokensStream()) {
+            assertThat(tokens).isEmpty();
+        }
+    }
+
+    @Test
+    @DisplayName("transactions commit on success and roll back on failure")
+    void transactionsCommitAndRollBack() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            var committed = UUID.randomUUID();
+            var created = q.withTransaction(tx -> {
+                tx.createUser(committed, "foo", "bar");
+                return tx.getUser(committed);
+            });
+            assertThat(created).isPresent();
+            assertThat(q.getUser(committed)).isPresent();
+
+            var rolledBack = UUID.randomUUID();
+            assertThatThrownBy(() -> q.inTransaction(tx -> {
+                tx.createUser(rolledBack, "baz", "qux");
+                throw new IllegalStateException("boom");
+            })).isInstanceOf(IllegalStateException.class);
+            assertThat(q.getUser(rolledBack)).isEmpty();
+            assertThat(conn.getAutoCommit()).isTrue();
+        }
+    }
+
+    @Test
+    @DisplayName("nested transactions roll back to a savepoint")
+    void nestedTransactionsRollBackToSavepoint() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            var outer = UUID.randomUUID();
+            var inner = UUID.randomUUID();
+            q.inTransaction(java.sql.Connection.TRANSACTION_SERIALIZABLE, false, tx -> {
+                tx.createUser(outer, "foo", "bar");
+                assertThatThrownBy(() -> tx.inTransaction(nested -> {
+                    nested.createUser(inner, "baz", "qux");
+                    throw new IllegalStateException("boom");
+                })).isInstanceOf(IllegalStateException.class);
+            });
+
+            assertThat(q.getUser(outer)).isPresent();
+            assertThat(q.getUser(inner)).isEmpty();
+            assertThat(conn.getTransactionIsolation()).isEqualTo(java.sql.Connection.TRANSACTION_READ_COMMITTED);
+        }
+    }
 }
@@ -0,0 +1,168 @@
+package io.github.tandemdude.sgj.r2dbc;
+
+import io.github.tandemdude.sgj.r2dbc.enums.Mood;
+import io.r2dbc.spi.Connection;
+import io.r2dbc.spi.ConnectionFactories;
+import io.r2dbc.spi.ConnectionFactory;
+import io.r2dbc.spi.ConnectionFactoryOptions;
+import io.r2dbc.spi.R2dbcDataIntegrityViolationException;
+import org.junit.jupiter.api.BeforeEach;
+import org.junit.jupiter.api.DisplayName;
+import org.junit.jupiter.api.Test;
+import org.testcontainers.containers.PostgreSQLContainer;
+import org.testcontainers.junit.jupiter.Container;
+import org.testcontainers.junit.jupiter.Testcontainers;
+import reactor.core.publisher.Mono;
+
+import java.time.LocalDateTime;
+import java.util.List;
+import java.util.Optional;
+import java.util.UUID;
+
+import static org.assertj.core.api.Assertions.assertThat;
+import static org.assertj.core.api.Assertions.assertThatThrownBy;
+
+@Testcontainers
+public class TestQueries {
+    @Container
+    private final PostgreSQLContainer<?> postgres = new PostgreSQLContainer<>("postgres:latest")
+        .withInitScript("postgres/schema.sql");
+
+    private ConnectionFactory connectionFactory;
+    private Queries q;
+
+    @BeforeEach
+    void setUp() {
+        connectionFactory = ConnectionFactories.get(ConnectionFactoryOptions.builder()
+            .option(ConnectionFactoryOptions.DRIVER, "postgresql")
+            .option(ConnectionFactoryOptions.HOST, postgres.getHost())
+            .option(ConnectionFactoryOptions.PORT, postgres.getMappedPort(PostgreSQLContainer.POSTGRESQL_PORT))
+            .option(ConnectionFactoryOptions.USER, postgres.getUsername())
+            .option(ConnectionFactoryOptions.PASSWORD, postgres.getPassword())
+            .option(ConnectionFactoryOptions.DATABASE, postgres.getDatabaseName())
+            .build());
+
+        q = new Queries(connectionFactory);
+    }
+
+    @Test
+    @DisplayName("GetUser returns the created user")
+    void getUserReturnsCreatedUser() {
+        assertThat(q.getUser(UUID.randomUUID()).blockOptional()).isEmpty();
+
+        var uid = UUID.randomUUID();
+        assertThat(q.createUser(uid, "foo", "foo@example.com").block()).isEqualTo(1L);
+
+        var found = q.getUser(uid).block();
+        assertThat(found).isNotNull();
+        assertThat(found.username()).isEqualTo("foo");
+        assertThat(found.email()).isEqualTo("foo@example.com");
+    }
+
+    @Test
+    @DisplayName("ListUsers returns every user")
+    void listUsersReturnsEveryUser() {
+        q.createUser(UUID.randomUUID(), "foo", "foo@example.com").block();
+        q.createUser(UUID.randomUUID(), "bar", "bar@example.com").block();
+
+        var users = q.listUsers().map(Queries.ListUsersRow::username).collectList().block();
+        assertThat(users).containsExactly("bar", "foo");
+    }
+
+    @Test
+    @DisplayName("enums are bound and read using their database value")
+    void enumsAreBoundAndRead() {
+        q.createPerson("foo", Mood.HAPPY, null).block();
+        q.createPerson("bar", Mood.SAD, Mood.OK).block();
+
+        var found = q.getPerson("bar").block();
+        assertThat(found).isNotNull();
+        assertThat(found.currentMood()).isEqualTo(Mood.SAD);
+        assertThat(found.nextMood()).isEqualTo(Mood.OK);
+        assertThat(q.getPerson("foo").map(Queries.GetPersonRow::nextMood).blockOptional()).isEmpty();
+
+        assertThat(q.listPeopleByMood(List.of(Mood.HAPPY, Mood.SAD)).collectList().block()).containsExactly("bar", "foo");
+        assertThat(q.listPeopleByMood(List.of(Mood.OK)).collectList().block()).isEmpty();
+    }
+
+    @Test
+    @DisplayName("arrays are bound and read as lists")
+    void arraysAreBoundAndRead() {
+        var created = q.createMessage(1, UUID.randomUUID(), "foo", List.of("bar", "baz")).block();
+        assertThat(created).isNotNull();
+        assertThat(q.getMessageAttachments(created).block()).contains(List.of("bar", "baz"));
+
+        var empty = q.createMessage(1, UUID.randomUUID(), "foo", null).block();
+        assertThat(empty).isNotNull();
+        assertThat(q.getMessageAttachments(empty).block()).isEmpty();
+    }
+
+    @Test
+    @DisplayName("DeleteUser returns the number of rows deleted")
+    void deleteUserReturnsRowsDeleted() {
+        var uid = UUID.randomUUID();
+        q.createUser(uid, "foo", "foo@example.com").block();
+
+        assertThat(q.deleteUser(uid).block()).isEqualTo(1L);
+        assertThat(q.deleteUser(uid).block()).isEqualTo(0L);
+    }
+
+    @Test
+    @DisplayName("copyfrom and batch queries return a result for each row")
+    void copyFromAndBatchQueriesReturnAResultForEachRow() {
+        var uid = UUID.randomUUID();
+        q.createUser(uid, "foo", "foo@example.com").block();
+
+        var expiry = LocalDateTime.of(2030, 1, 1, 12, 0);
+        var inserted = q.createTokens(List.of(
+            new Queries.CreateTokensParams(uid, "a", expiry),
+            new Queries.CreateTokensParams(uid, "b", expiry)
+        )).block();
+        assertThat(inserted).isEqualTo(2L);
+        assertThat(q.getToken("b").map(Queries.GetTokenRow::userId).block()).isEqualTo(uid);
+
+        var counts = q.upsertUsernames(List.of(
+            new Queries.UpsertUsernamesParams(uid, "foo2"),
+            new Queries.UpsertUsernamesParams(UUID.randomUUID(), "bar")
+        )).collectList().block();
+        assertThat(counts).containsExactly(1L, 0L);
+
+        var ids = q.createTokensReturningId(List.of(
+            new Queries.CreateTokensReturningIdParams(uid, "c", expiry),
+            new Queries.CreateTokensReturningIdParams(uid, "d", expiry)
+        )).collectList().block();
+        assertThat(ids).hasSize(2).allMatch(Optional::isPresent);
+        assertThat(q.getToken("d").map(Queries.GetTokenRow::tokenId).blockOptional()).isEqualTo(ids.get(1));
+    }
+
+    @Test
+    @DisplayName("errors are propagated through the returned publisher")
+    void errorsArePropagated() {
+        var uid = UUID.randomUUID();
+        q.createUser(uid, "foo", "foo@example.com").block();
+
+        assertThatThrownBy(() -> q.createUser(uid, "foo", "foo@example.com").block())
+            .isInstanceOf(R2dbcDataIntegrityViolationException.class);
+    }
+
+    @Test
+    @DisplayName("queries run on a connection participate in its transaction")
+    void queriesParticipateInConnectionTransaction() {
+        var uid = UUID.randomUUID();
+
+        Mono.usingWhen(
+            connectionFactory.create(),
+            conn -> {
+                var tx = new Queries(conn);
+                return Mono.from(conn.beginTransaction())
+                    .then(Mono.defer(() -> tx.createUser(uid, "foo", "foo@example.com")))
+                    .then(Mono.defer(() -> tx.getUser(uid)))
+                    .doOnNext(user -> assertThat(user.username()).isEqualTo("foo"))
+                    .then(Mono.from(conn.rollbackTransaction()));
+            },
+            Connection::close
+        ).block();
+
+        assertThat(q.getUser(uid).blockOptional()).isEmpty();
+    }
+}
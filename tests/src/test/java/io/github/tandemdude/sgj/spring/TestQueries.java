@@ -0,0 +1,159 @@
+package io.github.tandemdude.sgj.spring;
+
+import io.github.tandemdude.sgj.spring.enums.Mood;
+import org.junit.jupiter.api.BeforeEach;
+import org.junit.jupiter.api.DisplayName;
+import org.junit.jupiter.api.Test;
+import org.postgresql.ds.PGSimpleDataSource;
+import org.springframework.dao.DataAccessException;
+import org.springframework.jdbc.core.JdbcTemplate;
+import org.springframework.jdbc.datasource.DataSourceTransactionManager;
+import org.springframework.transaction.support.TransactionTemplate;
+import org.testcontainers.containers.PostgreSQLContainer;
+import org.testcontainers.junit.jupiter.Container;
+import org.testcontainers.junit.jupiter.Testcontainers;
+
+import java.time.LocalDateTime;
+import java.util.List;
+import java.util.Optional;
+import java.util.UUID;
+
+import static org.assertj.core.api.Assertions.assertThat;
+import static org.assertj.core.api.Assertions.assertThatThrownBy;
+
+@Testcontainers
+public class TestQueries {
+    @Container
+    private final PostgreSQLContainer<?> postgres = new PostgreSQLContainer<>("postgres:latest")
+        .withInitScript("postgres/schema.sql");
+
+    private PGSimpleDataSource dataSource;
+    private Queries q;
+
+    @BeforeEach
+    void setUp() {
+        dataSource = new PGSimpleDataSource();
+        dataSource.setUrl(postgres.getJdbcUrl());
+        dataSource.setUser(postgres.getUsername());
+        dataSource.setPassword(postgres.getPassword());
+
+        q = new Queries(new JdbcTemplate(dataSource));
+    }
+
+    @Test
+    @DisplayName("GetUser returns the created user")
+    void getUserReturnsCreatedUser() {
+        assertThat(q.getUser(UUID.randomUUID())).isEmpty();
+
+        var uid = UUID.randomUUID();
+        q.createUser(uid, "foo", "foo@example.com");
+
+        var found = q.getUser(uid);
+        assertThat(found).isPresent();
+        assertThat(found.get().username()).isEqualTo("foo");
+        assertThat(found.get().email()).isEqualTo("foo@example.com");
+    }
+
+    @Test
+    @DisplayName("ListUsers and its streaming variant return every user")
+    void listUsersReturnsEveryUser() {
+        q.createUser(UUID.randomUUID(), "foo", "foo@example.com");
+        q.createUser(UUID.randomUUID(), "bar", "bar@example.com");
+
+        assertThat(q.listUsers()).extracting(Queries.ListUsersRow::username).containsExactly("bar", "foo");
+        try (var users = q.listUsersStream()) {
+            assertThat(users.map(Queries.ListUsersRow::username)).containsExactly("bar", "foo");
+        }
+    }
+
+    @Test
+    @DisplayName("enums are bound and read using their database value")
+    void enumsAreBoundAndRead() {
+        q.createPerson("foo", Mood.HAPPY, null);
+        q.createPerson("bar", Mood.SAD, Mood.OK);
+
+        var found = q.getPerson("bar");
+        assertThat(found).isPresent();
+        assertThat(found.get().currentMood()).isEqualTo(Mood.SAD);
+        assertThat(found.get().nextMood()).isEqualTo(Mood.OK);
+        assertThat(q.getPerson("foo")).map(Queries.GetPersonRow::nextMood).isEmpty();
+
+        assertThat(q.listPeopleByMood(List.of(Mood.HAPPY, Mood.SAD))).containsExactly("bar", "foo");
+        assertThat(q.listPeopleByMood(List.of(Mood.OK))).isEmpty();
+    }
+
+    @Test
+    @DisplayName("arrays are bound and read as lists")
+    void arraysAreBoundAndRead() {
+        var created = q.createMessage(1, UUID.randomUUID(), "foo", List.of("bar", "baz"));
+        assertThat(created).isPresent();
+        assertThat(q.getMessageAttachments(created.get())).contains(List.of("bar", "baz"));
+
+        var empty = q.createMessage(1, UUID.randomUUID(), "foo", null);
+        assertThat(empty).isPresent();
+        assertThat(q.getMessageAttachments(empty.get())).isEmpty();
+    }
+
+    @Test
+    @DisplayName("DeleteUser returns the number of rows deleted")
+    void deleteUserReturnsRowsDeleted() {
+        var uid = UUID.randomUUID();
+        q.createUser(uid, "foo", "foo@example.com");
+
+        assertThat(q.deleteUser(uid)).isEqualTo(1);
+        assertThat(q.deleteUser(uid)).isEqualTo(0);
+    }
+
+    @Test
+    @DisplayName("copyfrom and batch queries return a result for each row")
+    void copyFromAndBatchQueriesReturnAResultForEachRow() {
+        var uid = UUID.randomUUID();
+        q.createUser(uid, "foo", "foo@example.com");
+
+        var expiry = LocalDateTime.of(2030, 1, 1, 12, 0);
+        var inserted = q.createTokens(List.of(
+            new Queries.CreateTokensParams(uid, "a", expiry),
+            new Queries.CreateTokensParams(uid, "b", expiry)
+        ));
+        assertThat(inserted).isEqualTo(2);
+        assertThat(q.getToken("b")).map(Queries.GetTokenRow::userId).contains(uid);
+
+        var counts = q.upsertUsernames(List.of(
+            new Queries.UpsertUsernamesParams(uid, "foo2"),
+            new Queries.UpsertUsernamesParams(UUID.randomUUID(), "bar")
+        ));
+        assertThat(counts).containsExactly(1, 0);
+
+        var ids = q.createTokensReturningId(List.of(
+            new Queries.CreateTokensReturningIdParams(uid, "c", expiry),
+            new Queries.CreateTokensReturningIdParams(uid, "d", expiry)
+        ));
+        assertThat(ids).hasSize(2).allMatch(Optional::isPresent);
+        assertThat(q.getToken("d")).map(Queries.GetTokenRow::tokenId).isEqualTo(ids.get(1));
+    }
+
+    @Test
+    @DisplayName("errors are translated into DataAccessExceptions")
+    void errorsAreTranslated() {
+        var uid = UUID.randomUUID();
+        q.createUser(uid, "foo", "foo@example.com");
+
+        assertThatThrownBy(() -> q.createUser(uid, "foo", "foo@example.com"))
+            .isInstanceOf(DataAccessException.class);
+    }
+
+    @Test
+    @DisplayName("queries participate in spring managed transactions")
+    void queriesParticipateInSpringTransactions() {
+        var tx = new TransactionTemplate(new DataSourceTransactionManager(dataSource));
+
+        var uid = UUID.randomUUID();
+        tx.executeWithoutResult(status -> {
+            q.createUser(uid, "foo", "foo@example.com");
+            assertThat(q.getUser(uid)).isPresent();
+            status.setRollbackOnly();
+        });
+
+        assertThat(q.getUser(uid)).isEmpty();
+    }
+}
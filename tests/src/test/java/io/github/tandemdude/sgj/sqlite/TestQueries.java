@@ -0,0 +1,103 @@
+package io.github.tandemdude.sgj.sqlite;
+
+import org.junit.jupiter.api.DisplayName;
+import org.junit.jupiter.api.Test;
+
+import java.io.IOException;
+import java.math.BigDecimal;
+import java.nio.charset.StandardCharsets;
+import java.sql.Connection;
+import java.sql.DriverManager;
+import java.sql.SQLException;
+import java.time.LocalDate;
+import java.util.Objects;
+
+import static org.assertj.core.api.Assertions.assertThat;
+
+public class TestQueries {
+    Connection getConn() throws SQLException, IOException {
+        var conn = DriverManager.getConnection("jdbc:sqlite::memory:");
+        conn.setAutoCommit(true);
+
+        try (var schema = Objects.requireNonNull(getClass().getClassLoader().getResourceAsStream("sqlite/schema.sql"))) {
+            var statements = new String(schema.readAllBytes(), StandardCharsets.UTF_8).split(";");
+            try (var stmt = conn.createStatement()) {
+                for (var statement : statements) {
+                    if (!statement.isBlank()) {
+                        stmt.execute(statement);
+                    }
+                }
+            }
+        }
+        return conn;
+    }
+
+    @Test
+    @DisplayName("GetAuthor returns empty optional when no records found")
+    void getAuthorReturnsEmptyOptionalNoRecordsFound() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            assertThat(q.getAuthor(1)).isEmpty();
+        }
+    }
+
+    @Test
+    @DisplayName("CreateAuthor returns generated key")
+    void createAuthorReturnsGeneratedKey() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            var id = q.createAuthor("foo", null);
+            var found = q.getAuthor(id);
+            assertThat(found).isPresent();
+            assertThat(found.get().name()).isEqualTo("foo");
+            assertThat(found.get().bio()).isNull();
+
+            q.createAuthor("bar", "baz");
+            assertThat(q.listAuthors()).hasSize(2);
+        }
+    }
+
+    @Test
+    @DisplayName("temporal and numeric types can be read and written")
+    void temporalAndNumericTypesCanBeReadAndWritten() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            var authorId = q.createAuthor("foo", null);
+            var published = LocalDate.of(2020, 1, 31);
+            var bookId = q.createBook(new Queries.CreateBookParams(authorId, "bar", new BigDecimal("12.50"), null, true, published, null));
+            assertThat(bookId).isPresent();
+
+            var found = q.getBook(bookId.get());
+            assertThat(found).isPresent();
+            assertThat(found.get().title()).isEqualTo("bar");
+            assertThat(found.get().price()).isEqualByComparingTo("12.5");
+            assertThat(found.get().rating()).isNull();
+            assertThat(found.get().available()).isTrue();
+            assertThat(found.get().published()).isEqualTo(published);
+            assertThat(found.get().createdAt()).isNotNull();
+            assertThat(found.get().cover()).isNull();
+
+            assertThat(q.deleteBooksByAuthor(authorId)).isEqualTo(1);
+        }
+    }
+
+    @Test
+    @DisplayName("colliding parameter and column names are disambiguated")
+    void collidingNamesAreDisambiguated() throws Exception {
+        try (var conn = getConn()) {
+            var q = new Queries(conn);
+
+            var authorId = q.createAuthor("foo", null);
+            q.createBook(new Queries.CreateBookParams(authorId, "bar", new BigDecimal("1.00"), null, true, LocalDate.of(2020, 1, 1), null));
+            q.createBook(new Queries.CreateBookParams(authorId, "baz", new BigDecimal("1.00"), null, true, LocalDate.of(2021, 1, 1), null));
+
+            var found = q.listBooksPublishedBetween(LocalDate.of(2019, 12, 1), LocalDate.of(2020, 2, 1));
+            assertThat(found).hasSize(1);
+            assertThat(found.get(0).title()).isEqualTo("bar");
+            assertThat(found.get(0).bookAuthorId()).isEqualTo(found.get(0).authorAuthorId());
+        }
+    }
+}
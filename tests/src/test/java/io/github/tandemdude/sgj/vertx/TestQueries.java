@@ -0,0 +1,179 @@
+package io.github.tandemdude.sgj.vertx;
+
+import io.github.tandemdude.sgj.vertx.enums.Mood;
+import io.vertx.core.Future;
+import io.vertx.pgclient.PgBuilder;
+import io.vertx.pgclient.PgConnectOptions;
+import io.vertx.pgclient.PgException;
+import io.vertx.sqlclient.Pool;
+import io.vertx.sqlclient.PoolOptions;
+import org.junit.jupiter.api.AfterEach;
+import org.junit.jupiter.api.BeforeEach;
+import org.junit.jupiter.api.DisplayName;
+import org.junit.jupiter.api.Test;
+import org.testcontainers.containers.PostgreSQLContainer;
+import org.testcontainers.junit.jupiter.Container;
+import org.testcontainers.junit.jupiter.Testcontainers;
+
+import java.time.LocalDateTime;
+import java.util.List;
+import java.util.Optional;
+import java.util.UUID;
+import java.util.concurrent.ExecutionException;
+import java.util.concurrent.TimeUnit;
+
+import static org.assertj.core.api.Assertions.assertThat;
+import static org.assertj.core.api.Assertions.assertThatThrownBy;
+
+@Testcontainers
+public class TestQueries {
+    @Container
+    private final PostgreSQLContainer<?> postgres = new PostgreSQLContainer<>("postgres:latest")
+        .withInitScript("postgres/schema.sql");
+
+    private Pool pool;
+    private Queries q;
+
+    static <T> T await(Future<T> future) throws Exception {
+        return future.toCompletionStage().toCompletableFuture().get(30, TimeUnit.SECONDS);
+    }
+
+    @BeforeEach
+    void setUp() {
+        pool = PgBuilder.pool()
+            .with(new PoolOptions().setMaxSize(4))
+            .connectingTo(new PgConnectOptions()
+                .setHost(postgres.getHost())
+                .setPort(postgres.getMappedPort(PostgreSQLContainer.POSTGRESQL_PORT))
+                .setUser(postgres.getUsername())
+                .setPassword(postgres.getPassword())
+                .setDatabase(postgres.getDatabaseName()))
+            .build();
+
+        q = new Queries(pool);
+    }
+
+    @AfterEach
+    void tearDown() throws Exception {
+        await(pool.close());
+    }
+
+    @Test
+    @DisplayName("GetUser returns the created user")
+    void getUserReturnsCreatedUser() throws Exception {
+        assertThat(await(q.getUser(UUID.randomUUID()))).isEmpty();
+
+        var uid = UUID.randomUUID();
+        await(q.createUser(uid, "foo", "foo@example.com"));
+
+        var found = await(q.getUser(uid));
+        assertThat(found).isPresent();
+        assertThat(found.get().username()).isEqualTo("foo");
+        assertThat(found.get().email()).isEqualTo("foo@example.com");
+    }
+
+    @Test
+    @DisplayName("ListUsers returns every user")
+    void listUsersReturnsEveryUser() throws Exception {
+        await(q.createUser(UUID.randomUUID(), "foo", "foo@example.com"));
+        await(q.createUser(UUID.randomUUID(), "bar", "bar@example.com"));
+
+        assertThat(await(q.listUsers())).extracting(Queries.ListUsersRow::username).containsExactly("bar", "foo");
+    }
+
+    @Test
+    @DisplayName("DeleteUser returns the number of rows deleted")
+    void deleteUserReturnsRowsDeleted() throws Exception {
+        var uid = UUID.randomUUID();
+        await(q.createUser(uid, "foo", "foo@example.com"));
+
+        assertThat(await(q.deleteUser(uid))).isEqualTo(1);
+        assertThat(await(q.deleteUser(uid))).isEqualTo(0);
+    }
+
+    @Test
+    @DisplayName("copyfrom and batch queries return a result for each row")
+    void copyFromAndBatchQueriesReturnAResultForEachRow() throws Exception {
+        var uid = UUID.randomUUID();
+        await(q.createUser(uid, "foo", "foo@example.com"));
+
+        var expiry = LocalDateTime.of(2030, 1, 1, 12, 0);
+        var inserted = await(q.createTokens(List.of(
+            new Queries.CreateTokensParams(uid, "a", expiry),
+            new Queries.CreateTokensParams(uid, "b", expiry)
+        )));
+        assertThat(inserted).isEqualTo(2L);
+        assertThat(await(q.getToken("b"))).map(Queries.GetTokenRow::userId).contains(uid);
+
+        var counts = await(q.upsertUsernames(List.of(
+            new Queries.UpsertUsernamesParams(uid, "foo2"),
+            new Queries.UpsertUsernamesParams(UUID.randomUUID(), "bar")
+        )));
+        assertThat(counts).containsExactly(1, 0);
+
+        var ids = await(q.createTokensReturningId(List.of(
+            new Queries.CreateTokensReturningIdParams(uid, "c", expiry),
+            new Queries.CreateTokensReturningIdParams(uid, "d", expiry)
+        )));
+        assertThat(ids).hasSize(2).allMatch(Optional::isPresent);
+        assertThat(await(q.getToken("d"))).map(Queries.GetTokenRow::tokenId).isEqualTo(ids.get(1));
+    }
+
+    @Test
+    @DisplayName("enums are bound and read using their database value")
+    void enumsAreBoundAndRead() throws Exception {
+        await(q.createPerson("foo", Mood.HAPPY, null));
+        await(q.createPerson("bar", Mood.SAD, Mood.OK));
+
+        var found = await(q.getPerson("bar"));
+        assertThat(found).isPresent();
+        assertThat(found.get().currentMood()).isEqualTo(Mood.SAD);
+        assertThat(found.get().nextMood()).isEqualTo(Mood.OK);
+        assertThat(await(q.getPerson("foo"))).map(Queries.GetPersonRow::nextMood).isEmpty();
+
+        assertThat(await(q.listPeopleByMood(List.of(Mood.HAPPY, Mood.SAD)))).containsExactly("bar", "foo");
+        assertThat(await(q.listPeopleByMood(List.of(Mood.OK)))).isEmpty();
+    }
+
+    @Test
+    @DisplayName("arrays are bound and read as lists")
+    void arraysAreBoundAndRead() throws Exception {
+        var created = await(q.createMessage(1, UUID.randomUUID(), "foo", List.of("bar", "baz")));
+        assertThat(created).isPresent();
+        assertThat(await(q.getMessageAttachments(created.get()))).contains(List.of("bar", "baz"));
+
+        var empty = await(q.createMessage(1, UUID.randomUUID(), "foo", null));
+        assertThat(empty).isPresent();
+        assertThat(await(q.getMessageAttachments(empty.get()))).isEmpty();
+    }
+
+    @Test
+    @DisplayName("errors fail the returned future")
+    void errorsFailTheFuture() throws Exception {
+        var uid = UUID.randomUUID();
+        await(q.createUser(uid, "foo", "foo@example.com"));
+
+        assertThatThrownBy(() -> await(q.createUser(uid, "foo", "foo@example.com")))
+            .isInstanceOf(ExecutionException.class)
+            .hasCauseInstanceOf(PgException.class);
+    }
+
+    @Test
+    @DisplayName("queries run on a connection participate in its transaction")
+    void queriesParticipateInConnectionTransaction() throws Exception {
+        var uid = UUID.randomUUID();
+
+        var result = pool.withTransaction(conn -> {
+            var tx = new Queries(conn);
+            return tx.createUser(uid, "foo", "foo@example.com")
+                .compose(v -> tx.getUser(uid))
+                .compose(user -> {
+                    assertThat(user).isPresent();
+                    return Future.<Void>failedFuture(new IllegalStateException("rollback"));
+                });
+        });
+        assertThatThrownBy(() -> await(result)).hasCauseInstanceOf(IllegalStateException.class);
+
+        assertThat(await(q.getUser(uid))).isEmpty();
+    }
+}
@@ -0,0 +1,90 @@
+package io.github.tandemdude.sgj.vertxmysql;
+
+import io.github.tandemdude.sgj.vertxmysql.enums.BooksBookType;
+import io.github.tandemdude.sgj.vertxmysql.enums.NullableEnumTestEnumField;
+import io.vertx.core.Future;
+import io.vertx.mysqlclient.MySQLBuilder;
+import io.vertx.mysqlclient.MySQLConnectOptions;
+import io.vertx.sqlclient.Pool;
+import io.vertx.sqlclient.PoolOptions;
+import org.junit.jupiter.api.AfterEach;
+import org.junit.jupiter.api.BeforeEach;
+import org.junit.jupiter.api.DisplayName;
+import org.junit.jupiter.api.Test;
+import org.testcontainers.containers.MySQLContainer;
+import org.testcontainers.junit.jupiter.Container;
+import org.testcontainers.junit.jupiter.Testcontainers;
+
+import java.time.LocalDateTime;
+import java.util.concurrent.TimeUnit;
+
+import static org.assertj.core.api.Assertions.assertThat;
+
+@Testcontainers
+public class TestQueries {
+    @Container
+    private final MySQLContainer<?> mysql = new MySQLContainer<>("mysql:latest")
+        .withInitScript("mysql/schema.sql");
+
+    private Pool pool;
+    private Queries q;
+
+    static <T> T await(Future<T> future) throws Exception {
+        return future.toCompletionStage().toCompletableFuture().get(30, TimeUnit.SECONDS);
+    }
+
+    @BeforeEach
+    void setUp() {
+        pool = MySQLBuilder.pool()
+            .with(new PoolOptions().setMaxSize(4))
+            .connectingTo(new MySQLConnectOptions()
+                .setHost(mysql.getHost())
+                .setPort(mysql.getMappedPort(MySQLContainer.MYSQL_PORT))
+                .setUser(mysql.getUsername())
+                .setPassword(mysql.getPassword())
+                .setDatabase(mysql.getDatabaseName()))
+            .build();
+
+        q = new Queries(pool);
+    }
+
+    @AfterEach
+    void tearDown() throws Exception {
+        await(pool.close());
+    }
+
+    @Test
+    @DisplayName("CreateAuthor returns the last inserted id")
+    void createAuthorReturnsLastInsertedId() throws Exception {
+        var first = await(q.createAuthor("foo"));
+        var second = await(q.createAuthor("bar"));
+        assertThat(second).isGreaterThan(first);
+
+        var found = await(q.getAuthor(second.intValue()));
+        assertThat(found).isPresent();
+        assertThat(found.get().name()).isEqualTo("bar");
+    }
+
+    @Test
+    @DisplayName("enums are bound and read using their database value")
+    void enumsAreBoundAndRead() throws Exception {
+        var authorId = await(q.createAuthor("foo"));
+        var bookId = await(q.createBook(authorId.intValue(), "foo", BooksBookType.NONFICTION, "bar", 2000, LocalDateTime.now(), "baz"));
+
+        var found = await(q.getBook(bookId.intValue()));
+        assertThat(found).isPresent();
+        assertThat(found.get().bookType()).isEqualTo(BooksBookType.NONFICTION);
+    }
+
+    @Test
+    @DisplayName("nullable enums are bound and read using their database value")
+    void nullableEnumsAreBoundAndRead() throws Exception {
+        var id = await(q.createEnumRow(NullableEnumTestEnumField.BAR));
+        assertThat(await(q.getEnumRow(id.intValue()))).map(Queries.GetEnumRowRow::enumField).contains(NullableEnumTestEnumField.BAR);
+
+        var nullId = await(q.createEnumRow(null));
+        var found = await(q.getEnumRow(nullId.intValue()));
+        assertThat(found).isPresent();
+        assertThat(found.get().enumField()).isNull();
+    }
+}